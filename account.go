@@ -0,0 +1,80 @@
+package openrouterapigo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrCreditsBelowThreshold is returned by EnsureCredits when the remaining
+// balance is lower than required.
+var ErrCreditsBelowThreshold = errors.New("openrouter: credits below threshold")
+
+// Credits is the credit balance of the account, in USD.
+type Credits struct {
+	TotalCredits float64 `json:"total_credits"`
+	TotalUsage   float64 `json:"total_usage"`
+}
+
+// Remaining returns the credits left to spend.
+func (c Credits) Remaining() float64 {
+	return c.TotalCredits - c.TotalUsage
+}
+
+// KeyInfo describes the API key used by the client, its spending limit and
+// usage, in USD.
+type KeyInfo struct {
+	Label             string        `json:"label"`
+	Usage             float64       `json:"usage"`
+	UsageDaily        float64       `json:"usage_daily"`
+	UsageWeekly       float64       `json:"usage_weekly"`
+	UsageMonthly      float64       `json:"usage_monthly"`
+	Limit             *float64      `json:"limit"`
+	LimitRemaining    *float64      `json:"limit_remaining"`
+	LimitReset        string        `json:"limit_reset,omitempty"`
+	IsFreeTier        bool          `json:"is_free_tier"`
+	IsProvisioningKey bool          `json:"is_provisioning_key"`
+	RateLimit         *KeyRateLimit `json:"rate_limit,omitempty"`
+}
+
+// KeyRateLimit is the request rate allowed for the key, e.g. 10 requests per
+// "10s".
+type KeyRateLimit struct {
+	Requests int    `json:"requests"`
+	Interval string `json:"interval"`
+}
+
+// GetCredits returns the credit balance of the account owning the API key.
+func (c *OpenRouterClient) GetCredits(ctx context.Context) (*Credits, error) {
+	output := struct {
+		Data Credits `json:"data"`
+	}{}
+	if err := c.getJSON(ctx, "/credits", &output); err != nil {
+		return nil, err
+	}
+	return &output.Data, nil
+}
+
+// GetKeyInfo returns the limits and usage of the API key used by the client.
+func (c *OpenRouterClient) GetKeyInfo(ctx context.Context) (*KeyInfo, error) {
+	output := struct {
+		Data KeyInfo `json:"data"`
+	}{}
+	if err := c.getJSON(ctx, "/key", &output); err != nil {
+		return nil, err
+	}
+	return &output.Data, nil
+}
+
+// EnsureCredits fails with an error wrapping ErrCreditsBelowThreshold when
+// less than minimum USD of credits remain, e.g. before a large batch job.
+func (c *OpenRouterClient) EnsureCredits(ctx context.Context, minimum float64) error {
+	credits, err := c.GetCredits(ctx)
+	if err != nil {
+		return err
+	}
+	if remaining := credits.Remaining(); remaining < minimum {
+		return fmt.Errorf("%w: %.4f remaining, %.4f required", ErrCreditsBelowThreshold, remaining, minimum)
+	}
+	return nil
+}
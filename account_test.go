@@ -0,0 +1,77 @@
+package openrouterapigo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newAccountServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error":{"code":401,"message":"No auth credentials found"}}`)
+			return
+		}
+		switch r.URL.Path {
+		case "/credits":
+			fmt.Fprint(w, `{"data":{"total_credits":10.5,"total_usage":8}}`)
+		case "/key":
+			fmt.Fprint(w, `{"data":{"label":"sk-or-v1-abc","usage":1.25,"limit":5,"limit_remaining":3.75,"is_free_tier":false,"rate_limit":{"requests":20,"interval":"10s"}}}`)
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+}
+
+func TestGetCreditsAndKeyInfo(t *testing.T) {
+	server := newAccountServer(t)
+	defer server.Close()
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client())
+	ctx := context.Background()
+
+	credits, err := client.GetCredits(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if credits.Remaining() != 2.5 {
+		t.Fatalf("unexpected remaining credits: %v", credits.Remaining())
+	}
+
+	key, err := client.GetKeyInfo(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key.Usage != 1.25 || key.Limit == nil || *key.Limit != 5 || *key.LimitRemaining != 3.75 {
+		t.Fatalf("unexpected key info: %+v", key)
+	}
+	if key.RateLimit == nil || key.RateLimit.Requests != 20 || key.RateLimit.Interval != "10s" {
+		t.Fatalf("unexpected rate limit: %+v", key.RateLimit)
+	}
+
+	unauthorized := NewOpenRouterClientFull("wrong", server.URL, server.Client())
+	_, err = unauthorized.GetCredits(ctx)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 APIError, got %v", err)
+	}
+}
+
+func TestEnsureCredits(t *testing.T) {
+	server := newAccountServer(t)
+	defer server.Close()
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client())
+	ctx := context.Background()
+
+	if err := client.EnsureCredits(ctx, 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := client.EnsureCredits(ctx, 3); !errors.Is(err, ErrCreditsBelowThreshold) {
+		t.Fatalf("expected ErrCreditsBelowThreshold, got %v", err)
+	}
+}
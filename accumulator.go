@@ -0,0 +1,168 @@
+package openrouterapigo
+
+// StreamAccumulator assembles the chunks of a chat completion stream into a
+// complete message: content and reasoning are concatenated, and fragmented
+// tool calls and reasoning details are merged by their index. Only the first choice is tracked.
+type StreamAccumulator struct {
+	id               string
+	model            string
+	created          int64
+	message          MessageResponse
+	toolIndexes      map[int]int
+	reasoningIndexes map[int]int
+	finishReason     string
+	usage            *ResponseUsage
+}
+
+func NewStreamAccumulator() *StreamAccumulator {
+	return &StreamAccumulator{
+		message:          MessageResponse{Role: RoleAssistant},
+		toolIndexes:      make(map[int]int),
+		reasoningIndexes: make(map[int]int),
+	}
+}
+
+// Add merges a stream chunk and returns the in-progress message.
+func (a *StreamAccumulator) Add(chunk Response) MessageResponse {
+	if a.id == "" {
+		a.id = chunk.ID
+	}
+	if a.model == "" {
+		a.model = chunk.Model
+	}
+	if a.created == 0 {
+		a.created = chunk.Created
+	}
+	if chunk.Usage != nil {
+		usage := *chunk.Usage
+		a.usage = &usage
+	}
+
+	if len(chunk.Choices) > 0 {
+		choice := chunk.Choices[0]
+		if choice.FinishReason != "" {
+			a.finishReason = choice.FinishReason
+		}
+		if choice.Delta != nil {
+			a.addDelta(*choice.Delta)
+		}
+	}
+
+	return a.Message()
+}
+
+func (a *StreamAccumulator) addDelta(delta Delta) {
+	if delta.Role != "" {
+		a.message.Role = MessageRole(delta.Role)
+	}
+	a.message.Content += delta.Content
+	a.message.Reasoning += delta.Reasoning
+	a.addReasoningDetails(delta.ReasoningDetails)
+
+	for _, fragment := range delta.ToolCalls {
+		index := len(a.message.ToolCalls)
+		if fragment.Index != nil {
+			index = *fragment.Index
+		} else if fragment.ID == "" && index > 0 {
+			// Without index and id the fragment continues the last call.
+			index--
+		}
+
+		position, ok := a.toolIndexes[index]
+		if !ok {
+			position = len(a.message.ToolCalls)
+			a.toolIndexes[index] = position
+			a.message.ToolCalls = append(a.message.ToolCalls, ToolCall{Type: string(DefaultToolType)})
+		}
+
+		call := &a.message.ToolCalls[position]
+		if fragment.ID != "" {
+			call.ID = fragment.ID
+		}
+		if fragment.Type != "" {
+			call.Type = fragment.Type
+		}
+		if fragment.Function.Name != "" {
+			call.Function.Name = fragment.Function.Name
+		}
+		call.Function.Arguments += fragment.Function.Arguments
+	}
+}
+
+// addReasoningDetails merges reasoning detail fragments. Fragments sharing an
+// index belong to the same block; fragments without index are whole blocks.
+func (a *StreamAccumulator) addReasoningDetails(fragments []ReasoningDetail) {
+	for _, fragment := range fragments {
+		if fragment.Index == nil {
+			a.message.ReasoningDetails = append(a.message.ReasoningDetails, fragment)
+			continue
+		}
+
+		position, ok := a.reasoningIndexes[*fragment.Index]
+		if !ok {
+			a.reasoningIndexes[*fragment.Index] = len(a.message.ReasoningDetails)
+			a.message.ReasoningDetails = append(a.message.ReasoningDetails, fragment)
+			continue
+		}
+
+		detail := &a.message.ReasoningDetails[position]
+		if fragment.Type != "" {
+			detail.Type = fragment.Type
+		}
+		if fragment.ID != "" {
+			detail.ID = fragment.ID
+		}
+		if fragment.Format != "" {
+			detail.Format = fragment.Format
+		}
+		if fragment.Signature != "" {
+			detail.Signature = fragment.Signature
+		}
+		detail.Summary += fragment.Summary
+		detail.Text += fragment.Text
+		detail.Data += fragment.Data
+	}
+}
+
+// Message returns the message assembled so far. After the stream ended it is
+// the final message.
+func (a *StreamAccumulator) Message() MessageResponse {
+	message := a.message
+	if len(a.message.ToolCalls) > 0 {
+		message.ToolCalls = append([]ToolCall(nil), a.message.ToolCalls...)
+	}
+	if len(a.message.ReasoningDetails) > 0 {
+		message.ReasoningDetails = append([]ReasoningDetail(nil), a.message.ReasoningDetails...)
+	}
+	return message
+}
+
+// FinishReason returns the finish reason of the stream, empty until the
+// final chunk arrived.
+func (a *StreamAccumulator) FinishReason() string {
+	return a.finishReason
+}
+
+// Usage returns the usage reported by the stream, nil if none was sent.
+func (a *StreamAccumulator) Usage() *ResponseUsage {
+	return a.usage
+}
+
+// Response returns the accumulated stream in the shape of a non-streaming
+// response.
+func (a *StreamAccumulator) Response() *Response {
+	message := a.Message()
+	return &Response{
+		ID:      a.id,
+		Model:   a.model,
+		Created: a.created,
+		Object:  "chat.completion",
+		Choices: []Choice{
+			{
+				FinishReason: a.finishReason,
+				Message:      &message,
+			},
+		},
+		Usage: a.usage,
+	}
+}
@@ -0,0 +1,88 @@
+package openrouterapigo
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestStreamAccumulator(t *testing.T) {
+	chunks := []string{
+		`{"id":"gen-1","model":"m","choices":[{"delta":{"role":"assistant","reasoning":"Think"}}]}`,
+		`{"id":"gen-1","choices":[{"delta":{"content":"Hel","reasoning":"ing"}}]}`,
+		`{"id":"gen-1","choices":[{"delta":{"content":"lo","tool_calls":[{"index":0,"id":"call_a","type":"function","function":{"name":"weather","arguments":"{\"ci"}}]}}]}`,
+		`{"id":"gen-1","choices":[{"delta":{"content":"","tool_calls":[{"index":1,"id":"call_b","function":{"name":"time","arguments":""}}]}}]}`,
+		`{"id":"gen-1","choices":[{"delta":{"content":"","tool_calls":[{"index":0,"function":{"arguments":"ty\":\"Oslo\"}"}},{"index":1,"function":{"arguments":"{}"}}]}}]}`,
+		`{"id":"gen-1","choices":[{"finish_reason":"tool_calls","delta":{"content":""}}],"usage":{"prompt_tokens":4,"completion_tokens":6,"total_tokens":10}}`,
+	}
+
+	acc := NewStreamAccumulator()
+	for i, raw := range chunks {
+		chunk := Response{}
+		if err := json.Unmarshal([]byte(raw), &chunk); err != nil {
+			t.Fatalf("bad chunk %d: %v", i, err)
+		}
+		snapshot := acc.Add(chunk)
+		if i == 1 && (snapshot.Content != "Hel" || snapshot.Reasoning != "Thinking") {
+			t.Fatalf("unexpected snapshot: %+v", snapshot)
+		}
+	}
+
+	message := acc.Message()
+	if message.Role != RoleAssistant || message.Content != "Hello" || message.Reasoning != "Thinking" {
+		t.Fatalf("unexpected message: %+v", message)
+	}
+	if len(message.ToolCalls) != 2 {
+		t.Fatalf("expected 2 tool calls, got %+v", message.ToolCalls)
+	}
+	first, second := message.ToolCalls[0], message.ToolCalls[1]
+	if first.ID != "call_a" || first.Function.Name != "weather" || first.Function.Arguments != `{"city":"Oslo"}` {
+		t.Fatalf("unexpected first tool call: %+v", first)
+	}
+	if second.ID != "call_b" || second.Type != "function" || second.Function.Arguments != "{}" {
+		t.Fatalf("unexpected second tool call: %+v", second)
+	}
+	if first.Index != nil || second.Index != nil {
+		t.Fatal("final tool calls must not carry stream indexes")
+	}
+	if acc.FinishReason() != "tool_calls" || acc.Usage() == nil || acc.Usage().TotalTokens != 10 {
+		t.Fatalf("unexpected finish reason or usage: %q %+v", acc.FinishReason(), acc.Usage())
+	}
+
+	resp := acc.Response()
+	if resp.ID != "gen-1" || resp.Model != "m" || resp.Choices[0].Message.Content != "Hello" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+
+	message.ToolCalls[0].ID = "changed"
+	if acc.Message().ToolCalls[0].ID != "call_a" {
+		t.Fatal("snapshots must not alias the accumulator state")
+	}
+}
+
+func TestStreamAccumulator_ReasoningDetails(t *testing.T) {
+	chunks := []string{
+		`{"choices":[{"delta":{"reasoning_details":[{"type":"reasoning.text","index":0,"text":"First "}]}}]}`,
+		`{"choices":[{"delta":{"reasoning_details":[{"type":"reasoning.text","index":0,"text":"step","signature":"sig"}]}}]}`,
+		`{"choices":[{"delta":{"reasoning_details":[{"type":"reasoning.encrypted","index":1,"data":"abc"}]}}]}`,
+	}
+
+	acc := NewStreamAccumulator()
+	for _, raw := range chunks {
+		chunk := Response{}
+		if err := json.Unmarshal([]byte(raw), &chunk); err != nil {
+			t.Fatalf("bad chunk: %v", err)
+		}
+		acc.Add(chunk)
+	}
+
+	details := acc.Message().ReasoningDetails
+	if len(details) != 2 {
+		t.Fatalf("expected 2 reasoning details, got %+v", details)
+	}
+	if details[0].Type != ReasoningDetailText || details[0].Text != "First step" || details[0].Signature != "sig" {
+		t.Fatalf("unexpected text detail: %+v", details[0])
+	}
+	if details[1].Type != ReasoningDetailEncrypted || details[1].Data != "abc" {
+		t.Fatalf("unexpected encrypted detail: %+v", details[1])
+	}
+}
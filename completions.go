@@ -0,0 +1,154 @@
+package openrouterapigo
+
+import (
+	"context"
+	"net/http"
+)
+
+// CompletionRequest represents the request structure of the legacy text
+// completions endpoint, for raw prompts to base models and fill-in-the-middle.
+type CompletionRequest struct {
+	Prompt            string               `json:"prompt"`
+	Suffix            string               `json:"suffix,omitempty"`
+	Echo              bool                 `json:"echo,omitempty"`
+	Logprobs          int                  `json:"logprobs,omitempty"`
+	Model             string               `json:"model,omitempty"`
+	Models            []string             `json:"models,omitempty"`
+	Stop              []string             `json:"stop,omitempty"`
+	Stream            bool                 `json:"stream,omitempty"`
+	MaxTokens         int                  `json:"max_tokens,omitempty"`
+	Temperature       float64              `json:"temperature,omitempty"`
+	Seed              int                  `json:"seed,omitempty"`
+	TopP              float64              `json:"top_p,omitempty"`
+	TopK              int                  `json:"top_k,omitempty"`
+	FrequencyPenalty  float64              `json:"frequency_penalty,omitempty"`
+	PresencePenalty   float64              `json:"presence_penalty,omitempty"`
+	RepetitionPenalty float64              `json:"repetition_penalty,omitempty"`
+	LogitBias         map[int]float64      `json:"logit_bias,omitempty"`
+	MinP              float64              `json:"min_p,omitempty"`
+	TopA              float64              `json:"top_a,omitempty"`
+	Transforms        []string             `json:"transforms,omitempty"`
+	Route             string               `json:"route,omitempty"`
+	Provider          *ProviderPreferences `json:"provider,omitempty"`
+	Usage             *UsageConfig         `json:"usage,omitempty"`
+	// Headers are sent with this request only, see Request.Headers.
+	Headers http.Header `json:"-"`
+}
+
+// CompletionResponse represents the response structure of the legacy text
+// completions endpoint. Stream chunks have the same shape.
+type CompletionResponse struct {
+	ID                string             `json:"id"`
+	Choices           []CompletionChoice `json:"choices"`
+	Created           int64              `json:"created"`
+	Model             string             `json:"model"`
+	Object            string             `json:"object"`
+	SystemFingerprint *string            `json:"system_fingerprint,omitempty"`
+	Usage             *ResponseUsage     `json:"usage,omitempty"`
+	Error             *ErrorResponse     `json:"error,omitempty"`
+}
+
+func (res CompletionResponse) streamError() *ErrorResponse {
+	if res.Error != nil {
+		return res.Error
+	}
+	for _, choice := range res.Choices {
+		if choice.Error != nil {
+			return choice.Error
+		}
+	}
+	return nil
+}
+
+func (res CompletionResponse) streamInfo() (string, string, bool, *ResponseUsage) {
+	hasOutput := false
+	for _, choice := range res.Choices {
+		if choice.Text != "" {
+			hasOutput = true
+		}
+	}
+	return res.ID, res.Model, hasOutput, res.Usage
+}
+
+type CompletionChoice struct {
+	Index        int                 `json:"index"`
+	Text         string              `json:"text"`
+	FinishReason string              `json:"finish_reason"`
+	Logprobs     *CompletionLogprobs `json:"logprobs,omitempty"`
+	Error        *ErrorResponse      `json:"error,omitempty"`
+}
+
+// CompletionLogprobs holds the per-token log probabilities requested with
+// CompletionRequest.Logprobs.
+type CompletionLogprobs struct {
+	Tokens        []string             `json:"tokens"`
+	TokenLogprobs []float64            `json:"token_logprobs"`
+	TopLogprobs   []map[string]float64 `json:"top_logprobs,omitempty"`
+	TextOffset    []int                `json:"text_offset,omitempty"`
+}
+
+func (c *OpenRouterClient) newCompletionsRequest(ctx context.Context, request CompletionRequest) (*http.Request, error) {
+	if request.Model == "" && len(request.Models) == 0 {
+		request.Model = c.defaultModel
+	}
+
+	provider := request.Provider
+	request.Provider = provider.routing()
+	req, err := c.newRequest(ctx, "POST", "/completions", request)
+	if err != nil {
+		return nil, err
+	}
+
+	setRequestHeaders(req, provider, request.Headers)
+	return req, nil
+}
+
+func (c *OpenRouterClient) FetchCompletions(request CompletionRequest) (*CompletionResponse, error) {
+	return c.FetchCompletionsContext(context.Background(), request)
+}
+
+// FetchCompletionsContext sends a prompt to the legacy text completions
+// endpoint. Interceptors do not apply, since they work on chat types.
+func (c *OpenRouterClient) FetchCompletionsContext(ctx context.Context, request CompletionRequest) (*CompletionResponse, error) {
+	request.Stream = false
+
+	output := &CompletionResponse{}
+	err := c.doJSON(ctx, func(ctx context.Context) (*http.Request, error) {
+		return c.newCompletionsRequest(ctx, request)
+	}, output)
+	if err != nil {
+		return nil, err
+	}
+
+	c.resolveGeneration(ctx, output.ID)
+	return output, nil
+}
+
+// FetchCompletionsStream streams a prompt completion from the legacy text
+// completions endpoint. It behaves like FetchChatCompletionsStream.
+func (c *OpenRouterClient) FetchCompletionsStream(request CompletionRequest, outputChan chan CompletionResponse, processingChan chan interface{}, errChan chan error, ctx context.Context) {
+	request.Stream = true
+
+	recorder := newStreamRecorder()
+	go func() {
+		defer close(errChan)
+		defer close(outputChan)
+		defer close(processingChan)
+
+		generationID := ""
+		err := streamWithRetry(c, ctx, func(ctx context.Context) (*http.Request, error) {
+			return c.newCompletionsRequest(ctx, request)
+		}, outputChan, processingChan, recorder, func(chunk *CompletionResponse) error {
+			if generationID == "" {
+				generationID = chunk.ID
+			}
+			return nil
+		})
+		c.finishStream(recorder, err)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		c.resolveGeneration(ctx, generationID)
+	}()
+}
@@ -0,0 +1,92 @@
+package openrouterapigo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFetchCompletions(t *testing.T) {
+	var got CompletionRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/completions" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprint(w, `{"id":"cmpl-1","object":"text_completion","choices":[{"index":0,"text":"middle","finish_reason":"stop","logprobs":{"tokens":["mid","dle"],"token_logprobs":[-0.1,-0.2]}}],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}`)
+	}))
+	defer server.Close()
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client())
+	resp, err := client.FetchCompletions(CompletionRequest{
+		Model:    "base/model",
+		Prompt:   "start ",
+		Suffix:   " end",
+		Echo:     true,
+		Logprobs: 2,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got.Prompt != "start " || got.Suffix != " end" || !got.Echo || got.Logprobs != 2 || got.Stream {
+		t.Fatalf("unexpected request: %+v", got)
+	}
+	if resp.Choices[0].Text != "middle" || resp.Choices[0].Logprobs == nil || len(resp.Choices[0].Logprobs.Tokens) != 2 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if resp.Usage == nil || resp.Usage.TotalTokens != 5 {
+		t.Fatalf("unexpected usage: %+v", resp.Usage)
+	}
+}
+
+func TestRouterAgent_CompletionRoutesToCompletions(t *testing.T) {
+	var paths []string
+	var streamed []bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		request := CompletionRequest{}
+		json.NewDecoder(r.Body).Decode(&request)
+		streamed = append(streamed, request.Stream)
+		if request.Stream {
+			fmt.Fprint(w, "data: {\"choices\":[{\"text\":\"Hel\"}]}\n\ndata: {\"choices\":[{\"text\":\"lo\"}]}\n\ndata: [DONE]\n\n")
+			return
+		}
+		fmt.Fprint(w, `{"choices":[{"text":"Hello"}]}`)
+	}))
+	defer server.Close()
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client())
+	agent := NewRouterAgent(client, "base/model", RouterAgentConfig{MaxTokens: 5})
+
+	resp, err := agent.Completion("Say")
+	if err != nil || resp.Choices[0].Text != "Hello" {
+		t.Fatalf("unexpected completion: %+v %v", resp, err)
+	}
+
+	outputChan := make(chan CompletionResponse)
+	processingChan := make(chan interface{})
+	errChan := make(chan error)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	agent.CompletionStream("Say", outputChan, processingChan, errChan, ctx)
+	text := ""
+	for chunk := range outputChan {
+		text += chunk.Choices[0].Text
+	}
+	if err := <-errChan; err != nil {
+		t.Fatalf("unexpected stream error: %v", err)
+	}
+
+	if text != "Hello" {
+		t.Fatalf("unexpected streamed text %q", text)
+	}
+	if fmt.Sprint(paths) != "[/completions /completions]" || fmt.Sprint(streamed) != "[false true]" {
+		t.Fatalf("unexpected requests: %v %v", paths, streamed)
+	}
+}
@@ -1,5 +1,7 @@
 package openrouterapigo
 
+import "net/http"
+
 // Request represents the main request structure.
 type Request struct {
 	Messages          []MessageRequest     `json:"messages,omitempty"`
@@ -27,8 +29,64 @@ type Request struct {
 	Models            []string             `json:"models,omitempty"`
 	Route             string               `json:"route,omitempty"`
 	Provider          *ProviderPreferences `json:"provider,omitempty"`
-	IncludeReasoning  bool                 `json:"include_reasoning,omitempty"`
-	Plugins           []Plugin             `json:"plugins,omitempty"`
+	// IncludeReasoning is the legacy switch for reasoning output; prefer
+	// Reasoning.
+	IncludeReasoning bool             `json:"include_reasoning,omitempty"`
+	Reasoning        *ReasoningConfig `json:"reasoning,omitempty"`
+	Plugins          []Plugin         `json:"plugins,omitempty"`
+	Usage            *UsageConfig     `json:"usage,omitempty"`
+	// Headers are sent with this request only, overriding the client
+	// headers, e.g. for per-request app attribution.
+	Headers http.Header `json:"-"`
+}
+
+// ReasoningConfig controls the reasoning tokens of models that support them.
+// Set either Effort or MaxTokens; Exclude keeps the reasoning out of the
+// response while the model still uses it.
+type ReasoningConfig struct {
+	Effort    ReasoningEffort `json:"effort,omitempty"`
+	MaxTokens int             `json:"max_tokens,omitempty"`
+	Exclude   bool            `json:"exclude,omitempty"`
+	// Enabled turns reasoning on with the default effort, or off when false.
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+type ReasoningEffort string
+
+const (
+	ReasoningEffortHigh    ReasoningEffort = "high"
+	ReasoningEffortMedium  ReasoningEffort = "medium"
+	ReasoningEffortLow     ReasoningEffort = "low"
+	ReasoningEffortMinimal ReasoningEffort = "minimal"
+)
+
+// ReasoningDetail is one block of structured reasoning. Which fields are set
+// depends on Type. Details must be sent back unchanged on assistant messages
+// so reasoning models keep their context, e.g. between tool calls.
+type ReasoningDetail struct {
+	Type      ReasoningDetailType `json:"type"`
+	ID        string              `json:"id,omitempty"`
+	Format    string              `json:"format,omitempty"`
+	Index     *int                `json:"index,omitempty"`
+	Summary   string              `json:"summary,omitempty"`
+	Text      string              `json:"text,omitempty"`
+	Signature string              `json:"signature,omitempty"`
+	// Data holds the encrypted reasoning of ReasoningDetailEncrypted blocks.
+	Data string `json:"data,omitempty"`
+}
+
+type ReasoningDetailType string
+
+const (
+	ReasoningDetailSummary   ReasoningDetailType = "reasoning.summary"
+	ReasoningDetailEncrypted ReasoningDetailType = "reasoning.encrypted"
+	ReasoningDetailText      ReasoningDetailType = "reasoning.text"
+)
+
+// UsageConfig enables usage accounting. With Include set, responses report
+// cost and token details, and streams end with a chunk carrying the usage.
+type UsageConfig struct {
+	Include bool `json:"include"`
 }
 
 type Plugin struct {
@@ -40,9 +98,27 @@ type PdfPlugin struct {
 	Engine string `json:"engine"`
 }
 
-// ResponseFormat represents the response format structure.
+// ResponseFormat represents the response format structure. Type is one of
+// the ResponseFormat* constants; JSONSchema is required for
+// ResponseFormatJSONSchema.
 type ResponseFormat struct {
-	Type string `json:"type"`
+	Type       string      `json:"type"`
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+}
+
+const (
+	ResponseFormatText       = "text"
+	ResponseFormatJSONObject = "json_object"
+	ResponseFormatJSONSchema = "json_schema"
+)
+
+// JSONSchema describes the structured output the model must produce. With
+// Strict set, providers that support it constrain decoding to the schema.
+type JSONSchema struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Strict      bool                   `json:"strict,omitempty"`
+	Schema      map[string]interface{} `json:"schema"`
 }
 
 // Prediction represents the prediction structure.
@@ -51,18 +127,77 @@ type Prediction struct {
 	Content string `json:"content"`
 }
 
-// ProviderPreferences represents the provider preferences structure.
+// ProviderPreferences controls how OpenRouter routes a request between the
+// providers serving the model.
+// https://openrouter.ai/docs/features/provider-routing
 type ProviderPreferences struct {
-	RefererURL string `json:"referer_url,omitempty"`
-	SiteName   string `json:"site_name,omitempty"`
+	// Order lists the providers to try first, in order.
+	Order []string `json:"order,omitempty"`
+	// Only restricts routing to the listed providers.
+	Only []string `json:"only,omitempty"`
+	// Ignore excludes the listed providers.
+	Ignore []string `json:"ignore,omitempty"`
+	// AllowFallbacks set to false disables falling back to providers not in
+	// Order. Nil keeps the default, which allows fallbacks.
+	AllowFallbacks *bool `json:"allow_fallbacks,omitempty"`
+	// RequireParameters routes only to providers supporting every parameter
+	// of the request.
+	RequireParameters bool `json:"require_parameters,omitempty"`
+	// DataCollection set to DataCollectionDeny excludes providers that may
+	// store or train on the data.
+	DataCollection DataCollection `json:"data_collection,omitempty"`
+	// ZDR restricts routing to zero data retention endpoints.
+	ZDR *bool `json:"zdr,omitempty"`
+	// Quantizations restricts routing to the listed quantization levels, e.g.
+	// "fp8" or "int4".
+	Quantizations []string `json:"quantizations,omitempty"`
+	// Sort orders providers by price, throughput or latency instead of the
+	// default load balancing.
+	Sort ProviderSort `json:"sort,omitempty"`
+	// MaxPrice excludes providers above the given prices.
+	MaxPrice *MaxPrice `json:"max_price,omitempty"`
+
+	// Deprecated: RefererURL is not a routing option and is not sent in the
+	// body. It is still sent as the HTTP-Referer header; use Request.Headers
+	// or WithAppAttribution instead.
+	RefererURL string `json:"-"`
+	// Deprecated: SiteName is not a routing option and is not sent in the
+	// body. It is still sent as the X-Title header; use Request.Headers or
+	// WithAppAttribution instead.
+	SiteName string `json:"-"`
+}
+
+type DataCollection string
+
+const (
+	DataCollectionAllow DataCollection = "allow"
+	DataCollectionDeny  DataCollection = "deny"
+)
+
+type ProviderSort string
+
+const (
+	ProviderSortPrice      ProviderSort = "price"
+	ProviderSortThroughput ProviderSort = "throughput"
+	ProviderSortLatency    ProviderSort = "latency"
+)
+
+// MaxPrice holds price limits in USD per million tokens, or per request or
+// image for those fields.
+type MaxPrice struct {
+	Prompt     float64 `json:"prompt,omitempty"`
+	Completion float64 `json:"completion,omitempty"`
+	Request    float64 `json:"request,omitempty"`
+	Image      float64 `json:"image,omitempty"`
 }
 
 type MessageRequest struct {
-	Role       MessageRole   `json:"role"`
-	Content    []ContentPart `json:"content,omitempty"`
-	Name       string        `json:"name,omitempty"`
-	ToolCallID string        `json:"tool_call_id,omitempty"`
-	ToolCalls  []ToolCall    `json:"tool_calls,omitempty"`
+	Role             MessageRole       `json:"role"`
+	Content          []ContentPart     `json:"content,omitempty"`
+	Name             string            `json:"name,omitempty"`
+	ToolCallID       string            `json:"tool_call_id,omitempty"`
+	ToolCalls        []ToolCall        `json:"tool_calls,omitempty"`
+	ReasoningDetails []ReasoningDetail `json:"reasoning_details,omitempty"`
 }
 
 func (req MessageRequest) GetRole() MessageRole {
@@ -81,6 +216,10 @@ func (req MessageRequest) GetReasoning() string {
 	return ""
 }
 
+func (req MessageRequest) GetReasoningDetails() []ReasoningDetail {
+	return req.ReasoningDetails
+}
+
 func (req MessageRequest) GetToolCallId() string {
 	return req.ToolCallID
 }
@@ -201,12 +340,58 @@ type Response struct {
 	Object            string         `json:"object"`
 	SystemFingerprint *string        `json:"system_fingerprint,omitempty"`
 	Usage             *ResponseUsage `json:"usage,omitempty"`
+	Error             *ErrorResponse `json:"error,omitempty"`
+}
+
+func (res Response) streamInfo() (string, string, bool, *ResponseUsage) {
+	hasOutput := false
+	for _, choice := range res.Choices {
+		if delta := choice.Delta; delta != nil && (delta.Content != "" || delta.Reasoning != "" || len(delta.ReasoningDetails) > 0 || len(delta.ToolCalls) > 0) {
+			hasOutput = true
+		}
+		if choice.Message != nil && (choice.Message.Content != "" || len(choice.Message.ToolCalls) > 0) {
+			hasOutput = true
+		}
+	}
+	return res.ID, res.Model, hasOutput, res.Usage
+}
+
+func (res Response) streamError() *ErrorResponse {
+	if res.Error != nil {
+		return res.Error
+	}
+	for _, choice := range res.Choices {
+		if choice.Error != nil {
+			return choice.Error
+		}
+	}
+	return nil
 }
 
 type ResponseUsage struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
 	TotalTokens      int `json:"total_tokens"`
+	// The fields below are reported when usage accounting is enabled with
+	// Request.Usage.
+	Cost                    float64                  `json:"cost,omitempty"`
+	IsBYOK                  bool                     `json:"is_byok,omitempty"`
+	PromptTokensDetails     *PromptTokensDetails     `json:"prompt_tokens_details,omitempty"`
+	CompletionTokensDetails *CompletionTokensDetails `json:"completion_tokens_details,omitempty"`
+	CostDetails             *CostDetails             `json:"cost_details,omitempty"`
+}
+
+type PromptTokensDetails struct {
+	CachedTokens int `json:"cached_tokens"`
+}
+
+type CompletionTokensDetails struct {
+	ReasoningTokens int `json:"reasoning_tokens"`
+}
+
+type CostDetails struct {
+	// UpstreamInferenceCost is what the provider charged, for BYOK requests.
+	UpstreamInferenceCost float64 `json:"upstream_inference_cost"`
 }
 
 type Choice struct {
@@ -218,10 +403,11 @@ type Choice struct {
 }
 
 type MessageResponse struct {
-	Content   string      `json:"content"`
-	Role      MessageRole `json:"role"`
-	Reasoning string      `json:"reasoning,omitempty"`
-	ToolCalls []ToolCall  `json:"tool_calls,omitempty"`
+	Content          string            `json:"content"`
+	Role             MessageRole       `json:"role"`
+	Reasoning        string            `json:"reasoning,omitempty"`
+	ReasoningDetails []ReasoningDetail `json:"reasoning_details,omitempty"`
+	ToolCalls        []ToolCall        `json:"tool_calls,omitempty"`
 }
 
 func (res MessageResponse) GetRole() MessageRole {
@@ -245,6 +431,10 @@ func (res MessageResponse) GetReasoning() string {
 	return res.Reasoning
 }
 
+func (res MessageResponse) GetReasoningDetails() []ReasoningDetail {
+	return res.ReasoningDetails
+}
+
 func (res MessageResponse) GetToolCallId() string {
 	return ""
 }
@@ -254,10 +444,11 @@ func (res MessageResponse) GetName() string {
 }
 
 type Delta struct {
-	Content   string     `json:"content"`
-	Role      string     `json:"role,omitempty"`
-	Reasoning string     `json:"reasoning,omitempty"`
-	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	Content          string            `json:"content"`
+	Role             string            `json:"role,omitempty"`
+	Reasoning        string            `json:"reasoning,omitempty"`
+	ReasoningDetails []ReasoningDetail `json:"reasoning_details,omitempty"`
+	ToolCalls        []ToolCall        `json:"tool_calls,omitempty"`
 }
 
 type ErrorResponse struct {
@@ -267,6 +458,9 @@ type ErrorResponse struct {
 }
 
 type ToolCall struct {
+	// Index identifies the tool call a stream delta belongs to. It is only
+	// set on stream deltas.
+	Index    *int             `json:"index,omitempty"`
 	ID       string           `json:"id"`
 	Type     string           `json:"type"`
 	Function ToolCallFunction `json:"function,omitempty"`
@@ -0,0 +1,134 @@
+package openrouterapigo
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+)
+
+type EmbeddingEncodingFormat string
+
+const (
+	EmbeddingEncodingFloat  EmbeddingEncodingFormat = "float"
+	EmbeddingEncodingBase64 EmbeddingEncodingFormat = "base64"
+)
+
+// EmbeddingInput holds the strings to embed. A single string is sent as a
+// plain string, more as a batch.
+type EmbeddingInput []string
+
+func (in EmbeddingInput) MarshalJSON() ([]byte, error) {
+	if len(in) == 1 {
+		return json.Marshal(in[0])
+	}
+	return json.Marshal([]string(in))
+}
+
+func (in *EmbeddingInput) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*in = EmbeddingInput{single}
+		return nil
+	}
+	var batch []string
+	if err := json.Unmarshal(data, &batch); err != nil {
+		return err
+	}
+	*in = batch
+	return nil
+}
+
+// EmbeddingRequest represents the embeddings request structure.
+type EmbeddingRequest struct {
+	Input          EmbeddingInput          `json:"input"`
+	Model          string                  `json:"model,omitempty"`
+	Dimensions     int                     `json:"dimensions,omitempty"`
+	EncodingFormat EmbeddingEncodingFormat `json:"encoding_format,omitempty"`
+	User           string                  `json:"user,omitempty"`
+}
+
+// EmbeddingResponse represents the embeddings response structure. Data is in
+// the order of the request input.
+type EmbeddingResponse struct {
+	ID     string          `json:"id,omitempty"`
+	Object string          `json:"object"`
+	Data   []Embedding     `json:"data"`
+	Model  string          `json:"model"`
+	Usage  *EmbeddingUsage `json:"usage,omitempty"`
+}
+
+type Embedding struct {
+	Object    string          `json:"object"`
+	Index     int             `json:"index"`
+	Embedding EmbeddingVector `json:"embedding"`
+}
+
+type EmbeddingUsage struct {
+	PromptTokens int     `json:"prompt_tokens"`
+	TotalTokens  int     `json:"total_tokens"`
+	Cost         float64 `json:"cost,omitempty"`
+}
+
+// EmbeddingVector is an embedding as floats. Vectors requested with
+// EmbeddingEncodingBase64 are decoded from little-endian float32 values.
+type EmbeddingVector []float64
+
+func (v *EmbeddingVector) UnmarshalJSON(data []byte) error {
+	var encoded string
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		var floats []float64
+		if err := json.Unmarshal(data, &floats); err != nil {
+			return err
+		}
+		*v = floats
+		return nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("invalid base64 embedding: %w", err)
+	}
+	if len(raw)%4 != 0 {
+		return fmt.Errorf("invalid base64 embedding length %d", len(raw))
+	}
+	floats := make([]float64, len(raw)/4)
+	for i := range floats {
+		floats[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(raw[i*4:])))
+	}
+	*v = floats
+	return nil
+}
+
+// Vectors returns the embeddings ordered by their input index.
+func (r EmbeddingResponse) Vectors() [][]float64 {
+	vectors := make([][]float64, len(r.Data))
+	for i, embedding := range r.Data {
+		index := embedding.Index
+		if index < 0 || index >= len(vectors) {
+			index = i
+		}
+		vectors[index] = embedding.Embedding
+	}
+	return vectors
+}
+
+// CreateEmbeddings embeds the request input, using the client auth, headers
+// and retry policy.
+func (c *OpenRouterClient) CreateEmbeddings(ctx context.Context, request EmbeddingRequest) (*EmbeddingResponse, error) {
+	if len(request.Input) == 0 {
+		return nil, fmt.Errorf("embedding input cannot be empty")
+	}
+
+	output := &EmbeddingResponse{}
+	err := c.doJSON(ctx, func(ctx context.Context) (*http.Request, error) {
+		return c.newRequest(ctx, "POST", "/embeddings", request)
+	}, output)
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
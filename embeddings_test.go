@@ -0,0 +1,102 @@
+package openrouterapigo
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCreateEmbeddings(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/embeddings" || r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		fmt.Fprint(w, `{"object":"list","model":"openai/text-embedding-3-small","data":[
+			{"object":"embedding","index":1,"embedding":[0.5,0.25]},
+			{"object":"embedding","index":0,"embedding":[1,2]}
+		],"usage":{"prompt_tokens":4,"total_tokens":4}}`)
+	}))
+	defer server.Close()
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client())
+	ctx := context.Background()
+
+	if _, err := client.CreateEmbeddings(ctx, EmbeddingRequest{Model: "openai/text-embedding-3-small", Input: EmbeddingInput{"one"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := client.CreateEmbeddings(ctx, EmbeddingRequest{
+		Model:          "openai/text-embedding-3-small",
+		Input:          EmbeddingInput{"one", "two"},
+		Dimensions:     2,
+		EncodingFormat: EmbeddingEncodingFloat,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if bodies[0] != `{"input":"one","model":"openai/text-embedding-3-small"}` {
+		t.Fatalf("unexpected single input body: %s", bodies[0])
+	}
+	if bodies[1] != `{"input":["one","two"],"model":"openai/text-embedding-3-small","dimensions":2,"encoding_format":"float"}` {
+		t.Fatalf("unexpected batch body: %s", bodies[1])
+	}
+
+	vectors := resp.Vectors()
+	if fmt.Sprint(vectors) != "[[1 2] [0.5 0.25]]" {
+		t.Fatalf("unexpected vectors: %v", vectors)
+	}
+	if resp.Usage == nil || resp.Usage.PromptTokens != 4 {
+		t.Fatalf("unexpected usage: %+v", resp.Usage)
+	}
+
+	if _, err := client.CreateEmbeddings(ctx, EmbeddingRequest{Model: "m"}); err == nil {
+		t.Fatal("expected error for empty input")
+	}
+}
+
+func TestCreateEmbeddings_Retry(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, `{"object":"list","data":[{"index":0,"embedding":[1]}]}`)
+	}))
+	defer server.Close()
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client(), WithRetryPolicy(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}))
+	if _, err := client.CreateEmbeddings(context.Background(), EmbeddingRequest{Model: "m", Input: EmbeddingInput{"x"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if atomic.LoadInt32(&attempts) != 2 {
+		t.Fatalf("expected retry, got %d attempts", attempts)
+	}
+}
+
+func TestEmbeddingVector_Base64(t *testing.T) {
+	raw := make([]byte, 8)
+	binary.LittleEndian.PutUint32(raw, math.Float32bits(1.5))
+	binary.LittleEndian.PutUint32(raw[4:], math.Float32bits(-0.25))
+	data, _ := json.Marshal(base64.StdEncoding.EncodeToString(raw))
+
+	var vector EmbeddingVector
+	if err := json.Unmarshal(data, &vector); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(vector) != 2 || vector[0] != 1.5 || vector[1] != -0.25 {
+		t.Fatalf("unexpected vector: %v", vector)
+	}
+}
@@ -0,0 +1,170 @@
+package openrouterapigo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIError is returned for every non-200 response from OpenRouter and for
+// error objects sent inside a stream. It carries the decoded error object, so
+// callers can branch on the failure kind with errors.As or the Is* helpers
+// instead of parsing strings.
+type APIError struct {
+	// StatusCode is the HTTP status of the response.
+	StatusCode int
+	// Code is the error.code value reported by OpenRouter. It usually mirrors
+	// StatusCode but may differ for provider errors.
+	Code int
+	// Message is the error.message value reported by OpenRouter.
+	Message string
+	// Metadata holds the decoded error.metadata object, if any.
+	Metadata *ErrorMetadata
+	// Headers are the response headers, e.g. for Retry-After or rate limit info.
+	Headers http.Header
+	// Body is the raw response body.
+	Body []byte
+}
+
+// ErrorMetadata represents the error.metadata object. Which fields are filled
+// depends on the kind of error.
+type ErrorMetadata struct {
+	// ProviderName is set for errors raised by the upstream provider.
+	ProviderName string `json:"provider_name,omitempty"`
+	// Raw is the error returned by the upstream provider, as sent.
+	Raw json.RawMessage `json:"raw,omitempty"`
+	// Reasons lists why the input was flagged by moderation.
+	Reasons []string `json:"reasons,omitempty"`
+	// FlaggedInput is the part of the input that was flagged by moderation.
+	FlaggedInput string `json:"flagged_input,omitempty"`
+	// ModelSlug is the model that rejected the request.
+	ModelSlug string `json:"model_slug,omitempty"`
+}
+
+type apiErrorBody struct {
+	Error *struct {
+		Code     int             `json:"code"`
+		Message  string          `json:"message"`
+		Metadata json.RawMessage `json:"metadata,omitempty"`
+	} `json:"error"`
+}
+
+func (e *APIError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "openrouter: status %d", e.StatusCode)
+	if e.Code != 0 && e.Code != e.StatusCode {
+		fmt.Fprintf(&sb, " (code %d)", e.Code)
+	}
+	if e.Metadata != nil && e.Metadata.ProviderName != "" {
+		fmt.Fprintf(&sb, " from %s", e.Metadata.ProviderName)
+	}
+	if e.Message != "" {
+		sb.WriteString(": ")
+		sb.WriteString(e.Message)
+	}
+	return sb.String()
+}
+
+// newAPIError builds an APIError from a response and its already read body.
+// Bodies that are not an OpenRouter error object are kept as the message.
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Headers:    resp.Header,
+		Body:       body,
+	}
+
+	decoded := apiErrorBody{}
+	if err := json.Unmarshal(body, &decoded); err != nil || decoded.Error == nil {
+		apiErr.Code = resp.StatusCode
+		apiErr.Message = strings.TrimSpace(string(body))
+		return apiErr
+	}
+
+	apiErr.Code = decoded.Error.Code
+	apiErr.Message = decoded.Error.Message
+	if len(decoded.Error.Metadata) > 0 && string(decoded.Error.Metadata) != "null" {
+		metadata := &ErrorMetadata{}
+		if err := json.Unmarshal(decoded.Error.Metadata, metadata); err == nil {
+			apiErr.Metadata = metadata
+		}
+	}
+	return apiErr
+}
+
+// newStreamAPIError builds an APIError from an error object sent inside a
+// stream chunk. StatusCode is the status of the stream response, usually 200;
+// Code carries the actual failure.
+func newStreamAPIError(resp *http.Response, errResp *ErrorResponse) *APIError {
+	body, _ := json.Marshal(errResp)
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Code:       errResp.Code,
+		Message:    errResp.Message,
+		Headers:    resp.Header,
+		Body:       body,
+	}
+	if len(errResp.Metadata) > 0 {
+		metadata := &ErrorMetadata{}
+		if data, err := json.Marshal(errResp.Metadata); err == nil && json.Unmarshal(data, metadata) == nil {
+			apiErr.Metadata = metadata
+		}
+	}
+	return apiErr
+}
+
+func asAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	return nil, false
+}
+
+func (e *APIError) hasCode(code int) bool {
+	return e.StatusCode == code || e.Code == code
+}
+
+// IsRateLimited reports whether err is an APIError caused by rate limiting (429).
+func IsRateLimited(err error) bool {
+	apiErr, ok := asAPIError(err)
+	return ok && apiErr.hasCode(http.StatusTooManyRequests)
+}
+
+// IsInsufficientCredits reports whether err is an APIError caused by an
+// account or key without enough credits (402).
+func IsInsufficientCredits(err error) bool {
+	apiErr, ok := asAPIError(err)
+	return ok && apiErr.hasCode(http.StatusPaymentRequired)
+}
+
+// IsModerated reports whether err is an APIError caused by the input being
+// flagged by moderation (403 with moderation reasons).
+func IsModerated(err error) bool {
+	apiErr, ok := asAPIError(err)
+	if !ok || !apiErr.hasCode(http.StatusForbidden) {
+		return false
+	}
+	return apiErr.Metadata != nil && (len(apiErr.Metadata.Reasons) > 0 || apiErr.Metadata.FlaggedInput != "")
+}
+
+// IsContextLengthExceeded reports whether err is an APIError caused by the
+// prompt not fitting into the model context window.
+func IsContextLengthExceeded(err error) bool {
+	apiErr, ok := asAPIError(err)
+	if !ok || !apiErr.hasCode(http.StatusBadRequest) {
+		return false
+	}
+	message := strings.ToLower(apiErr.Message)
+	if apiErr.Metadata != nil {
+		message += " " + strings.ToLower(string(apiErr.Metadata.Raw))
+	}
+	for _, marker := range []string{"context length", "context_length", "context window", "maximum context", "too many tokens"} {
+		if strings.Contains(message, marker) {
+			return true
+		}
+	}
+	return false
+}
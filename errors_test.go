@@ -0,0 +1,160 @@
+package openrouterapigo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFetchChatCompletions_APIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3")
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"error":{"code":429,"message":"Rate limit exceeded","metadata":{"provider_name":"Foo","raw":{"detail":"slow down"}}}}`)
+	}))
+	defer server.Close()
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client())
+	_, err := client.FetchChatCompletions(Request{Model: "m"})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *APIError, got %T", err)
+	}
+	if apiErr.StatusCode != http.StatusTooManyRequests || apiErr.Code != 429 {
+		t.Fatalf("unexpected status/code: %d/%d", apiErr.StatusCode, apiErr.Code)
+	}
+	if apiErr.Message != "Rate limit exceeded" {
+		t.Fatalf("unexpected message: %q", apiErr.Message)
+	}
+	if apiErr.Metadata == nil || apiErr.Metadata.ProviderName != "Foo" {
+		t.Fatalf("unexpected metadata: %+v", apiErr.Metadata)
+	}
+	if string(apiErr.Metadata.Raw) != `{"detail":"slow down"}` {
+		t.Fatalf("unexpected raw metadata: %s", apiErr.Metadata.Raw)
+	}
+	if apiErr.Headers.Get("Retry-After") != "3" {
+		t.Fatalf("expected headers to be kept")
+	}
+	if !IsRateLimited(err) || IsInsufficientCredits(err) {
+		t.Fatalf("unexpected classification")
+	}
+}
+
+func TestFetchChatCompletionsStream_APIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusPaymentRequired)
+		fmt.Fprint(w, `{"error":{"code":402,"message":"Insufficient credits"}}`)
+	}))
+	defer server.Close()
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client())
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	stream := client.StartChatCompletionsStream(Request{Model: "m", Stream: true}, ctx)
+	for {
+		ev, ok := stream.Recv(ctx)
+		if ev.Err != nil {
+			if !IsInsufficientCredits(ev.Err) {
+				t.Fatalf("expected insufficient credits error, got %v", ev.Err)
+			}
+			return
+		}
+		if !ok {
+			t.Fatal("expected error before stream end")
+		}
+	}
+}
+
+func TestAPIErrorClassification(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		check   func(error) bool
+		matches bool
+	}{
+		{"moderated", 403, `{"error":{"code":403,"message":"flagged","metadata":{"reasons":["violence"],"flagged_input":"..."}}}`, IsModerated, true},
+		{"forbidden without reasons", 403, `{"error":{"code":403,"message":"forbidden"}}`, IsModerated, false},
+		{"context length", 400, `{"error":{"code":400,"message":"This endpoint's maximum context length is 8192 tokens"}}`, IsContextLengthExceeded, true},
+		{"other bad request", 400, `{"error":{"code":400,"message":"invalid model"}}`, IsContextLengthExceeded, false},
+		{"plain text body", 429, `too many requests`, IsRateLimited, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := fmt.Errorf("wrapped: %w", newAPIError(&http.Response{StatusCode: tt.status}, []byte(tt.body)))
+			if tt.check(err) != tt.matches {
+				t.Fatalf("expected %v for %s", tt.matches, err)
+			}
+		})
+	}
+
+	if IsRateLimited(errors.New("429")) {
+		t.Fatal("plain errors must not be classified")
+	}
+}
+
+func TestFetchChatCompletionsStream_MidStreamErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		chunk string
+		code  int
+		check func(error) bool
+	}{
+		{"top-level error", `{"id":"a","error":{"code":429,"message":"Provider rate limited","metadata":{"provider_name":"Foo"}},"choices":[]}`, 429, IsRateLimited},
+		{"choice error", `{"id":"a","choices":[{"finish_reason":"error","delta":{"content":""},"error":{"code":402,"message":"Out of credits"}}]}`, 402, IsInsufficientCredits},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				fmt.Fprint(w, "data: {\"id\":\"a\",\"choices\":[{\"delta\":{\"content\":\"partial\"}}]}\n\n")
+				fmt.Fprintf(w, "data: %s\n\n", tt.chunk)
+			}))
+			defer server.Close()
+
+			client := NewOpenRouterClientFull("key", server.URL, server.Client(), WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}))
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			stream := client.StartChatCompletionsStream(Request{Model: "m", Stream: true}, ctx)
+			chunks := 0
+			var streamErr error
+			for {
+				ev, ok := stream.Recv(ctx)
+				if ev.Err != nil {
+					streamErr = ev.Err
+					break
+				}
+				if !ok {
+					break
+				}
+				if ev.Response != nil {
+					chunks++
+				}
+			}
+
+			if chunks != 1 {
+				t.Fatalf("expected only the chunk before the error, got %d", chunks)
+			}
+			var apiErr *APIError
+			if !errors.As(streamErr, &apiErr) || apiErr.Code != tt.code || !tt.check(streamErr) {
+				t.Fatalf("expected APIError with code %d, got %v", tt.code, streamErr)
+			}
+			if attempts != 1 {
+				t.Fatalf("stream must not be retried after delivering chunks, got %d attempts", attempts)
+			}
+		})
+	}
+}
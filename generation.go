@@ -0,0 +1,104 @@
+package openrouterapigo
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Generation is the accounting record OpenRouter keeps for every completion,
+// with the exact cost and the token counts reported by the provider.
+type Generation struct {
+	ID                     string    `json:"id"`
+	TotalCost              float64   `json:"total_cost"`
+	CacheDiscount          float64   `json:"cache_discount"`
+	UpstreamInferenceCost  float64   `json:"upstream_inference_cost"`
+	Usage                  float64   `json:"usage"`
+	CreatedAt              time.Time `json:"created_at"`
+	Model                  string    `json:"model"`
+	Origin                 string    `json:"origin"`
+	IsBYOK                 bool      `json:"is_byok"`
+	UpstreamID             string    `json:"upstream_id"`
+	ProviderName           string    `json:"provider_name"`
+	Streamed               bool      `json:"streamed"`
+	Cancelled              bool      `json:"cancelled"`
+	Latency                int       `json:"latency"`
+	ModerationLatency      int       `json:"moderation_latency"`
+	GenerationTime         int       `json:"generation_time"`
+	FinishReason           string    `json:"finish_reason"`
+	NativeFinishReason     string    `json:"native_finish_reason"`
+	TokensPrompt           int       `json:"tokens_prompt"`
+	TokensCompletion       int       `json:"tokens_completion"`
+	NativeTokensPrompt     int       `json:"native_tokens_prompt"`
+	NativeTokensCompletion int       `json:"native_tokens_completion"`
+	NativeTokensReasoning  int       `json:"native_tokens_reasoning"`
+	NativeTokensCached     int       `json:"native_tokens_cached"`
+	NumMediaPrompt         int       `json:"num_media_prompt"`
+	NumMediaCompletion     int       `json:"num_media_completion"`
+	NumSearchResults       int       `json:"num_search_results"`
+}
+
+// LatencyDuration returns Latency, reported in milliseconds, as a duration.
+func (g Generation) LatencyDuration() time.Duration {
+	return time.Duration(g.Latency) * time.Millisecond
+}
+
+// GetGeneration returns the generation record for the id of a completion
+// response. Records become available shortly after the completion ends;
+// until then the call fails with a 404 APIError.
+func (c *OpenRouterClient) GetGeneration(ctx context.Context, id string) (*Generation, error) {
+	output := struct {
+		Data Generation `json:"data"`
+	}{}
+	if err := c.getJSON(ctx, "/generation?id="+url.QueryEscape(id), &output); err != nil {
+		return nil, err
+	}
+	return &output.Data, nil
+}
+
+// GenerationHook receives the generation record resolved after a completion,
+// or the error that prevented resolving it.
+type GenerationHook func(id string, generation *Generation, err error)
+
+// generationLookupDelays are the waits before each lookup attempt made for a
+// GenerationHook. Lookups stop at the first answer that is not a 404.
+var generationLookupDelays = []time.Duration{
+	500 * time.Millisecond,
+	time.Second,
+	2 * time.Second,
+	4 * time.Second,
+	8 * time.Second,
+}
+
+// WithGenerationHook makes the client resolve the generation record of every
+// completion in the background and pass it to hook, e.g. for billing
+// reconciliation. The hook is called from its own goroutine.
+func WithGenerationHook(hook GenerationHook) ClientOption {
+	return func(c *OpenRouterClient) {
+		c.generationHook = hook
+	}
+}
+
+// resolveGeneration starts the background lookup for a GenerationHook. The
+// lookup keeps the values of ctx but not its cancellation, since the caller
+// usually finishes before the record is available.
+func (c *OpenRouterClient) resolveGeneration(ctx context.Context, id string) {
+	if c.generationHook == nil || id == "" {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		var generation *Generation
+		var err error
+		for _, delay := range generationLookupDelays {
+			time.Sleep(delay)
+			generation, err = c.GetGeneration(ctx, id)
+			if apiErr, ok := asAPIError(err); !ok || apiErr.StatusCode != http.StatusNotFound {
+				break
+			}
+		}
+		c.generationHook(id, generation, err)
+	}()
+}
@@ -0,0 +1,105 @@
+package openrouterapigo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const generationFixture = `{"data":{
+	"id":"gen-123",
+	"total_cost":0.00042,
+	"cache_discount":0.0001,
+	"created_at":"2025-03-01T12:00:00.123Z",
+	"model":"openai/gpt-4o",
+	"provider_name":"OpenAI",
+	"streamed":false,
+	"latency":850,
+	"generation_time":700,
+	"finish_reason":"stop",
+	"tokens_prompt":10,
+	"tokens_completion":20,
+	"native_tokens_prompt":12,
+	"native_tokens_completion":21,
+	"native_tokens_reasoning":null
+}}`
+
+func TestGetGeneration(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/generation" || r.URL.Query().Get("id") != "gen-123" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		fmt.Fprint(w, generationFixture)
+	}))
+	defer server.Close()
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client())
+	generation, err := client.GetGeneration(context.Background(), "gen-123")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if generation.TotalCost != 0.00042 || generation.ProviderName != "OpenAI" {
+		t.Fatalf("unexpected generation: %+v", generation)
+	}
+	if generation.NativeTokensPrompt != 12 || generation.NativeTokensCompletion != 21 {
+		t.Fatalf("unexpected native tokens: %+v", generation)
+	}
+	if generation.LatencyDuration() != 850*time.Millisecond {
+		t.Fatalf("unexpected latency: %v", generation.LatencyDuration())
+	}
+	if generation.CreatedAt.Year() != 2025 {
+		t.Fatalf("unexpected created_at: %v", generation.CreatedAt)
+	}
+}
+
+func TestGenerationHook(t *testing.T) {
+	delays := generationLookupDelays
+	generationLookupDelays = []time.Duration{time.Millisecond, time.Millisecond, time.Millisecond}
+	defer func() { generationLookupDelays = delays }()
+
+	var lookups int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/chat/completions":
+			fmt.Fprint(w, `{"id":"gen-123","choices":[]}`)
+		case "/generation":
+			if atomic.AddInt32(&lookups, 1) == 1 {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"error":{"code":404,"message":"Generation not found"}}`)
+				return
+			}
+			fmt.Fprint(w, generationFixture)
+		}
+	}))
+	defer server.Close()
+
+	type result struct {
+		id         string
+		generation *Generation
+		err        error
+	}
+	results := make(chan result, 1)
+	client := NewOpenRouterClientFull("key", server.URL, server.Client(), WithGenerationHook(func(id string, generation *Generation, err error) {
+		results <- result{id, generation, err}
+	}))
+
+	if _, err := client.FetchChatCompletions(Request{Model: "m"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case res := <-results:
+		if res.err != nil || res.id != "gen-123" || res.generation.TotalCost != 0.00042 {
+			t.Fatalf("unexpected hook result: %+v", res)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("generation hook was not called")
+	}
+	if atomic.LoadInt32(&lookups) != 2 {
+		t.Fatalf("expected lookup to be retried after 404, got %d lookups", lookups)
+	}
+}
@@ -0,0 +1,77 @@
+package openrouterapigo
+
+import "context"
+
+// Interceptor sees typed chat completion traffic of the client. It applies to
+// FetchChatCompletions and FetchChatCompletionsStream and everything built on
+// them. Interceptors run once per call, around the retries; use Middleware
+// to observe individual HTTP attempts.
+type Interceptor interface {
+	// InterceptRequest is called before the request is marshaled and may
+	// modify it. Returning a non-nil response skips the HTTP call, e.g. to
+	// serve a cached answer; for streams it is delivered as the only chunk.
+	InterceptRequest(ctx context.Context, request *Request) (*Response, error)
+	// InterceptResponse is called after a response is decoded and may modify
+	// it. For streams it is called for every chunk.
+	InterceptResponse(ctx context.Context, request *Request, response *Response) error
+}
+
+// InterceptorFuncs implements Interceptor with optional functions.
+type InterceptorFuncs struct {
+	Request  func(ctx context.Context, request *Request) (*Response, error)
+	Response func(ctx context.Context, request *Request, response *Response) error
+}
+
+func (f InterceptorFuncs) InterceptRequest(ctx context.Context, request *Request) (*Response, error) {
+	if f.Request == nil {
+		return nil, nil
+	}
+	return f.Request(ctx, request)
+}
+
+func (f InterceptorFuncs) InterceptResponse(ctx context.Context, request *Request, response *Response) error {
+	if f.Response == nil {
+		return nil
+	}
+	return f.Response(ctx, request, response)
+}
+
+// WithInterceptors appends interceptors. Requests pass through them in the
+// given order and responses in reverse order, so the first interceptor wraps
+// all others.
+func WithInterceptors(interceptors ...Interceptor) ClientOption {
+	return func(c *OpenRouterClient) {
+		c.interceptors = append(c.interceptors, interceptors...)
+	}
+}
+
+// interceptRequest fills in the default model and runs the request
+// interceptors, so they see the request as it will be sent. It returns the
+// number of interceptors that must see the response: all of them, or the ones
+// before the interceptor that produced a short-circuit response.
+func (c *OpenRouterClient) interceptRequest(ctx context.Context, request *Request) (*Response, int, error) {
+	if request.Model == "" && len(request.Models) == 0 {
+		request.Model = c.defaultModel
+	}
+	for i, interceptor := range c.interceptors {
+		response, err := interceptor.InterceptRequest(ctx, request)
+		if err != nil {
+			return nil, 0, err
+		}
+		if response != nil {
+			return response, i, nil
+		}
+	}
+	return nil, len(c.interceptors), nil
+}
+
+// interceptResponse runs the response interceptors of the first n request
+// interceptors, last one first.
+func (c *OpenRouterClient) interceptResponse(ctx context.Context, request *Request, response *Response, n int) error {
+	for i := n - 1; i >= 0; i-- {
+		if err := c.interceptors[i].InterceptResponse(ctx, request, response); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package openrouterapigo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestInterceptors_OrderAndModification(t *testing.T) {
+	var gotRequest Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&gotRequest)
+		fmt.Fprint(w, `{"id":"resp","choices":[{"message":{"role":"assistant","content":"secret"}}]}`)
+	}))
+	defer server.Close()
+
+	var calls []string
+	named := func(name string) Interceptor {
+		return InterceptorFuncs{
+			Request: func(ctx context.Context, request *Request) (*Response, error) {
+				calls = append(calls, "request "+name)
+				request.Transforms = append(request.Transforms, name)
+				return nil, nil
+			},
+			Response: func(ctx context.Context, request *Request, response *Response) error {
+				calls = append(calls, "response "+name)
+				return nil
+			},
+		}
+	}
+	redact := InterceptorFuncs{
+		Response: func(ctx context.Context, request *Request, response *Response) error {
+			response.Choices[0].Message.Content = "[redacted]"
+			return nil
+		},
+	}
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client(), WithInterceptors(named("a"), named("b"), redact))
+
+	resp, err := client.FetchChatCompletions(Request{Model: "m"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"request a", "request b", "response b", "response a"}
+	if fmt.Sprint(calls) != fmt.Sprint(expected) {
+		t.Fatalf("expected %v, got %v", expected, calls)
+	}
+	if fmt.Sprint(gotRequest.Transforms) != "[a b]" {
+		t.Fatalf("request modifications not sent: %v", gotRequest.Transforms)
+	}
+	if resp.Choices[0].Message.Content != "[redacted]" {
+		t.Fatalf("response modification lost: %q", resp.Choices[0].Message.Content)
+	}
+}
+
+func TestInterceptors_ShortCircuit(t *testing.T) {
+	cached := &Response{ID: "cached"}
+	seenByOuter := false
+	client := NewOpenRouterClientFull("key", "http://invalid.invalid", http.DefaultClient, WithInterceptors(
+		InterceptorFuncs{
+			Response: func(ctx context.Context, request *Request, response *Response) error {
+				seenByOuter = response.ID == "cached"
+				return nil
+			},
+		},
+		InterceptorFuncs{
+			Request: func(ctx context.Context, request *Request) (*Response, error) {
+				return cached, nil
+			},
+		},
+	))
+
+	resp, err := client.FetchChatCompletions(Request{Model: "m"})
+	if err != nil || resp.ID != "cached" {
+		t.Fatalf("expected cached response, got %v %v", resp, err)
+	}
+	if !seenByOuter {
+		t.Fatal("outer interceptor did not see the cached response")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	stream := client.StartChatCompletionsStream(Request{Model: "m", Stream: true}, ctx)
+	ev, ok := stream.Recv(ctx)
+	if !ok || ev.Response == nil || ev.Response.ID != "cached" {
+		t.Fatalf("expected cached chunk, got %+v", ev)
+	}
+}
+
+func TestInterceptors_SeeDefaultModel(t *testing.T) {
+	var models []string
+	client := NewOpenRouterClientFull("key", "http://invalid.invalid", http.DefaultClient, WithDefaultModel("default/model"), WithInterceptors(
+		InterceptorFuncs{
+			Request: func(ctx context.Context, request *Request) (*Response, error) {
+				models = append(models, request.Model)
+				return &Response{ID: "cached"}, nil
+			},
+		},
+	))
+
+	if _, err := client.FetchChatCompletions(Request{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	stream := client.StartChatCompletionsStream(Request{Stream: true}, ctx)
+	if _, ok := stream.Recv(ctx); !ok {
+		t.Fatal("expected cached chunk")
+	}
+	if _, err := client.FetchChatCompletions(Request{Models: []string{"a", "b"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if fmt.Sprint(models) != "[default/model default/model ]" {
+		t.Fatalf("expected interceptors to see the default model, got %q", models)
+	}
+}
+
+func TestInterceptors_StreamChunks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "data: {\"id\":\"1\"}\n\ndata: {\"id\":\"2\"}\n\ndata: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	chunks := 0
+	client := NewOpenRouterClientFull("key", server.URL, server.Client(), WithInterceptors(InterceptorFuncs{
+		Response: func(ctx context.Context, request *Request, response *Response) error {
+			chunks++
+			response.Model = "seen"
+			return nil
+		},
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	stream := client.StartChatCompletionsStream(Request{Model: "m", Stream: true}, ctx)
+	for {
+		ev, ok := stream.Recv(ctx)
+		if ev.Err != nil {
+			t.Fatalf("unexpected error: %v", ev.Err)
+		}
+		if !ok {
+			break
+		}
+		if ev.Response != nil && ev.Response.Model != "seen" {
+			t.Fatalf("chunk not intercepted: %+v", ev.Response)
+		}
+	}
+	if chunks != 2 {
+		t.Fatalf("expected 2 intercepted chunks, got %d", chunks)
+	}
+}
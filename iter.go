@@ -0,0 +1,71 @@
+//go:build go1.23
+
+package openrouterapigo
+
+import (
+	"context"
+	"iter"
+)
+
+// ChatCompletionsSeq streams request and yields its chunks. Stopping the loop
+// early cancels the request. A failure is yielded once, as the last element.
+func (c *OpenRouterClient) ChatCompletionsSeq(ctx context.Context, request Request) iter.Seq2[Response, error] {
+	return func(yield func(Response, error) bool) {
+		stream := c.StartChatCompletionsStream(request, ctx)
+		defer stream.Close()
+
+		for {
+			event, ok := stream.Recv(ctx)
+			if event.Err != nil {
+				yield(Response{}, event.Err)
+				return
+			}
+			if !ok {
+				return
+			}
+			if event.Response == nil {
+				continue
+			}
+			if !yield(*event.Response, nil) {
+				return
+			}
+		}
+	}
+}
+
+// ChatCompletionsText is like ChatCompletionsSeq but yields only the content
+// deltas of the first choice.
+func (c *OpenRouterClient) ChatCompletionsText(ctx context.Context, request Request) iter.Seq2[string, error] {
+	return contentDeltas(c.ChatCompletionsSeq(ctx, request))
+}
+
+// ChatSeq streams a chat completion for messages and yields its chunks.
+func (agent RouterAgent) ChatSeq(ctx context.Context, messages []MessageRequest) iter.Seq2[Response, error] {
+	request := agent.newRequest(true)
+	request.Messages = messages
+
+	return agent.client.ChatCompletionsSeq(ctx, request)
+}
+
+// ChatText streams a chat completion for messages and yields only the content
+// deltas.
+func (agent RouterAgent) ChatText(ctx context.Context, messages []MessageRequest) iter.Seq2[string, error] {
+	return contentDeltas(agent.ChatSeq(ctx, messages))
+}
+
+func contentDeltas(chunks iter.Seq2[Response, error]) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		for chunk, err := range chunks {
+			if err != nil {
+				yield("", err)
+				return
+			}
+			if len(chunk.Choices) == 0 || chunk.Choices[0].Delta == nil || chunk.Choices[0].Delta.Content == "" {
+				continue
+			}
+			if !yield(chunk.Choices[0].Delta.Content, nil) {
+				return
+			}
+		}
+	}
+}
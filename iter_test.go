@@ -0,0 +1,107 @@
+//go:build go1.23
+
+package openrouterapigo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChatCompletionsSeq(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if body, _ := io.ReadAll(r.Body); !strings.Contains(string(body), `"stream":true`) {
+			t.Errorf("expected a streaming request, got %s", body)
+		}
+		fmt.Fprint(w, ": OPENROUTER PROCESSING\n\n")
+		fmt.Fprint(w, "data: {\"id\":\"a\",\"choices\":[{\"delta\":{\"role\":\"assistant\",\"content\":\"\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"id\":\"a\",\"choices\":[{\"delta\":{\"content\":\"Hel\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"id\":\"a\",\"choices\":[{\"delta\":{\"content\":\"lo\"}}]}\n\ndata: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client())
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	chunks := 0
+	for chunk, err := range client.ChatCompletionsSeq(ctx, Request{Model: "m"}) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if chunk.ID != "a" {
+			t.Fatalf("unexpected chunk: %+v", chunk)
+		}
+		chunks++
+	}
+	if chunks != 3 {
+		t.Fatalf("expected 3 chunks, got %d", chunks)
+	}
+
+	agent := NewRouterAgent(client, "m", RouterAgentConfig{})
+	text := ""
+	for delta, err := range agent.ChatText(ctx, []MessageRequest{{Role: RoleUser, Content: TextContent("hi")}}) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		text += delta
+	}
+	if text != "Hello" {
+		t.Fatalf("unexpected text %q", text)
+	}
+}
+
+func TestChatCompletionsSeq_BreakCancelsRequest(t *testing.T) {
+	cancelled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; ; i++ {
+			if _, err := fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":\"%d\"}}]}\n\n", i); err != nil {
+				break
+			}
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+				close(cancelled)
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}))
+	defer server.Close()
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client())
+	for _, err := range client.ChatCompletionsText(context.Background(), Request{Model: "m"}) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		break
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("request was not cancelled after breaking out of the loop")
+	}
+}
+
+func TestChatCompletionsSeq_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"error":{"code":429,"message":"slow down"}}`)
+	}))
+	defer server.Close()
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client())
+	var errs []error
+	for _, err := range client.ChatCompletionsSeq(context.Background(), Request{Model: "m"}) {
+		errs = append(errs, err)
+	}
+	if len(errs) != 1 || !IsRateLimited(errs[0]) {
+		t.Fatalf("expected a single rate limit error, got %v", errs)
+	}
+}
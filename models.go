@@ -0,0 +1,241 @@
+package openrouterapigo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrModelNotFound is returned by GetModel for ids missing from the catalog.
+var ErrModelNotFound = errors.New("openrouter: model not found")
+
+// DefaultModelsCacheTTL is how long ListModels reuses the fetched catalog
+// unless changed with WithModelsCacheTTL.
+const DefaultModelsCacheTTL = 5 * time.Minute
+
+// Price is an amount in USD. OpenRouter sends prices as decimal strings.
+type Price float64
+
+func (p *Price) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		var number float64
+		if err := json.Unmarshal(data, &number); err != nil {
+			return fmt.Errorf("invalid price %s: %w", data, err)
+		}
+		*p = Price(number)
+		return nil
+	}
+	if value == "" {
+		*p = 0
+		return nil
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("invalid price %q: %w", value, err)
+	}
+	*p = Price(number)
+	return nil
+}
+
+func (p Price) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strconv.FormatFloat(float64(p), 'f', -1, 64))
+}
+
+// Model describes a model from the OpenRouter catalog.
+type Model struct {
+	ID                  string            `json:"id"`
+	CanonicalSlug       string            `json:"canonical_slug,omitempty"`
+	HuggingFaceID       string            `json:"hugging_face_id,omitempty"`
+	Name                string            `json:"name"`
+	Created             int64             `json:"created"`
+	Description         string            `json:"description,omitempty"`
+	ContextLength       int               `json:"context_length"`
+	Architecture        ModelArchitecture `json:"architecture"`
+	Pricing             ModelPricing      `json:"pricing"`
+	TopProvider         ModelTopProvider  `json:"top_provider"`
+	PerRequestLimits    map[string]any    `json:"per_request_limits,omitempty"`
+	SupportedParameters []string          `json:"supported_parameters,omitempty"`
+}
+
+// ModelArchitecture describes the modalities and tokenizer of a model.
+type ModelArchitecture struct {
+	Modality         string   `json:"modality"`
+	InputModalities  []string `json:"input_modalities"`
+	OutputModalities []string `json:"output_modalities"`
+	Tokenizer        string   `json:"tokenizer"`
+	InstructType     string   `json:"instruct_type,omitempty"`
+}
+
+// ModelPricing holds the price per token (prompt, completion, reasoning,
+// cache), per image, per web search and per request.
+type ModelPricing struct {
+	Prompt            Price `json:"prompt"`
+	Completion        Price `json:"completion"`
+	Request           Price `json:"request"`
+	Image             Price `json:"image"`
+	WebSearch         Price `json:"web_search,omitempty"`
+	InternalReasoning Price `json:"internal_reasoning,omitempty"`
+	InputCacheRead    Price `json:"input_cache_read,omitempty"`
+	InputCacheWrite   Price `json:"input_cache_write,omitempty"`
+}
+
+// ModelTopProvider holds the limits of the provider OpenRouter routes to first.
+type ModelTopProvider struct {
+	ContextLength       int  `json:"context_length"`
+	MaxCompletionTokens int  `json:"max_completion_tokens"`
+	IsModerated         bool `json:"is_moderated"`
+}
+
+// SupportsParameter reports whether the model accepts the given request
+// parameter, e.g. "tools" or "response_format".
+func (m Model) SupportsParameter(name string) bool {
+	for _, parameter := range m.SupportedParameters {
+		if parameter == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsInputModality reports whether the model accepts the given input
+// modality, e.g. "image" or "file".
+func (m Model) SupportsInputModality(modality string) bool {
+	for _, supported := range m.Architecture.InputModalities {
+		if supported == modality {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateRequest checks the request against the parameters, modalities and
+// limits of the model, so unsupported requests can be rejected before they
+// are sent. All problems found are joined into the returned error.
+func (m Model) ValidateRequest(request Request) error {
+	var errs []error
+	requireParameter := func(set bool, name string) {
+		if set && !m.SupportsParameter(name) {
+			errs = append(errs, fmt.Errorf("model %s does not support parameter %q", m.ID, name))
+		}
+	}
+
+	requireParameter(len(request.Tools) > 0, "tools")
+	requireParameter(request.ToolChoice != nil, "tool_choice")
+	requireParameter(request.ResponseFormat != nil, "response_format")
+	requireParameter(request.MaxTokens != 0, "max_tokens")
+	requireParameter(request.Temperature != 0, "temperature")
+	requireParameter(request.TopP != 0, "top_p")
+	requireParameter(request.TopK != 0, "top_k")
+	requireParameter(request.Seed != 0, "seed")
+	requireParameter(len(request.Stop) > 0, "stop")
+	requireParameter(request.FrequencyPenalty != 0, "frequency_penalty")
+	requireParameter(request.PresencePenalty != 0, "presence_penalty")
+	requireParameter(request.RepetitionPenalty != 0, "repetition_penalty")
+	requireParameter(len(request.LogitBias) > 0, "logit_bias")
+	requireParameter(request.TopLogprobs != 0, "top_logprobs")
+	requireParameter(request.MinP != 0, "min_p")
+	requireParameter(request.TopA != 0, "top_a")
+
+	if m.TopProvider.MaxCompletionTokens > 0 && request.MaxTokens > m.TopProvider.MaxCompletionTokens {
+		errs = append(errs, fmt.Errorf("max_tokens %d exceeds model %s limit of %d", request.MaxTokens, m.ID, m.TopProvider.MaxCompletionTokens))
+	}
+
+	for _, msg := range request.Messages {
+		for _, part := range msg.Content {
+			switch part.Type {
+			case ContentTypeImage:
+				if !m.SupportsInputModality("image") {
+					errs = append(errs, fmt.Errorf("model %s does not accept image input", m.ID))
+				}
+			case ContentTypePDF:
+				if !m.SupportsInputModality("file") {
+					errs = append(errs, fmt.Errorf("model %s does not accept file input", m.ID))
+				}
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+type modelsCache struct {
+	mu        sync.Mutex
+	models    []Model
+	fetchedAt time.Time
+}
+
+func (mc *modelsCache) get(ttl time.Duration) []Model {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if ttl > 0 && mc.models != nil && time.Since(mc.fetchedAt) < ttl {
+		return mc.models
+	}
+	return nil
+}
+
+func (mc *modelsCache) set(models []Model) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.models = models
+	mc.fetchedAt = time.Now()
+}
+
+// WithModelsCacheTTL sets how long ListModels and GetModel reuse the fetched
+// catalog. A zero or negative ttl disables caching.
+func WithModelsCacheTTL(ttl time.Duration) ClientOption {
+	return func(c *OpenRouterClient) {
+		c.modelsCacheTTL = ttl
+	}
+}
+
+// ListModels returns the OpenRouter model catalog. Results are cached for the
+// configured TTL; the returned slice must not be modified.
+func (c *OpenRouterClient) ListModels(ctx context.Context) ([]Model, error) {
+	if models := c.modelsCache.get(c.modelsCacheTTL); models != nil {
+		return models, nil
+	}
+
+	// The lock is not held during the fetch, so concurrent callers are not
+	// stuck behind a slow request and still honour their own ctx.
+	output := struct {
+		Data []Model `json:"data"`
+	}{}
+	if err := c.getJSON(ctx, "/models", &output); err != nil {
+		return nil, err
+	}
+	if output.Data == nil {
+		output.Data = []Model{}
+	}
+
+	c.modelsCache.set(output.Data)
+	return output.Data, nil
+}
+
+// GetModel returns the catalog entry of the model with the given id. It
+// returns an error wrapping ErrModelNotFound for unknown ids.
+func (c *OpenRouterClient) GetModel(ctx context.Context, id string) (*Model, error) {
+	models, err := c.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range models {
+		if models[i].ID == id || models[i].CanonicalSlug == id {
+			model := models[i]
+			return &model, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrModelNotFound, id)
+}
+
+// ClearModelsCache drops the cached catalog, so the next ListModels call
+// fetches it again.
+func (c *OpenRouterClient) ClearModelsCache() {
+	c.modelsCache.mu.Lock()
+	defer c.modelsCache.mu.Unlock()
+	c.modelsCache.models = nil
+}
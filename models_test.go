@@ -0,0 +1,167 @@
+package openrouterapigo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const modelsFixture = `{"data":[{
+	"id":"openai/gpt-4o",
+	"canonical_slug":"openai/gpt-4o-2024-05-13",
+	"name":"OpenAI: GPT-4o",
+	"created":1715558400,
+	"context_length":128000,
+	"architecture":{"modality":"text+image->text","input_modalities":["text","image"],"output_modalities":["text"],"tokenizer":"GPT","instruct_type":null},
+	"pricing":{"prompt":"0.0000025","completion":"0.00001","request":"0","image":"0.003613"},
+	"top_provider":{"context_length":128000,"max_completion_tokens":16384,"is_moderated":true},
+	"per_request_limits":null,
+	"supported_parameters":["tools","tool_choice","max_tokens","temperature","response_format"]
+}]}`
+
+func newModelsServer(t *testing.T, requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		if r.Method != "GET" || r.URL.Path != "/models" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("missing authorization header")
+		}
+		fmt.Fprint(w, modelsFixture)
+	}))
+}
+
+func TestListModels(t *testing.T) {
+	var requests int32
+	server := newModelsServer(t, &requests)
+	defer server.Close()
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client())
+	models, err := client.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(models) != 1 {
+		t.Fatalf("expected 1 model, got %d", len(models))
+	}
+
+	model := models[0]
+	if model.ID != "openai/gpt-4o" || model.ContextLength != 128000 {
+		t.Fatalf("unexpected model: %+v", model)
+	}
+	if model.Pricing.Prompt != 0.0000025 || model.Pricing.Completion != 0.00001 || model.Pricing.Image != 0.003613 {
+		t.Fatalf("unexpected pricing: %+v", model.Pricing)
+	}
+	if model.TopProvider.MaxCompletionTokens != 16384 || !model.TopProvider.IsModerated {
+		t.Fatalf("unexpected top provider: %+v", model.TopProvider)
+	}
+	if !model.SupportsParameter("tools") || model.SupportsParameter("seed") {
+		t.Fatalf("unexpected supported parameters: %v", model.SupportedParameters)
+	}
+	if !model.SupportsInputModality("image") || model.SupportsInputModality("file") {
+		t.Fatalf("unexpected modalities: %v", model.Architecture.InputModalities)
+	}
+}
+
+func TestModelsCache(t *testing.T) {
+	var requests int32
+	server := newModelsServer(t, &requests)
+	defer server.Close()
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client(), WithModelsCacheTTL(time.Hour))
+	ctx := context.Background()
+
+	if _, err := client.GetModel(ctx, "openai/gpt-4o"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.GetModel(ctx, "openai/gpt-4o-2024-05-13"); err != nil {
+		t.Fatalf("expected lookup by canonical slug, got %v", err)
+	}
+	if _, err := client.GetModel(ctx, "missing/model"); !errors.Is(err, ErrModelNotFound) {
+		t.Fatalf("expected ErrModelNotFound, got %v", err)
+	}
+	if atomic.LoadInt32(&requests) != 1 {
+		t.Fatalf("expected cached catalog, got %d requests", requests)
+	}
+
+	client.ClearModelsCache()
+	if _, err := client.ListModels(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if atomic.LoadInt32(&requests) != 2 {
+		t.Fatalf("expected refetch after clear, got %d requests", requests)
+	}
+
+	uncached := NewOpenRouterClientFull("key", server.URL, server.Client(), WithModelsCacheTTL(0))
+	uncached.ListModels(ctx)
+	uncached.ListModels(ctx)
+	if atomic.LoadInt32(&requests) != 4 {
+		t.Fatalf("expected no caching with zero ttl, got %d requests", requests)
+	}
+}
+
+func TestModelsCache_ConcurrentFetchHonoursContext(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			return
+		}
+		fmt.Fprint(w, modelsFixture)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client(), WithModelsCacheTTL(time.Hour))
+	slowCtx, cancelSlow := context.WithCancel(context.Background())
+	defer cancelSlow()
+	go client.ListModels(slowCtx)
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := client.ListModels(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("caller waited %s behind another fetch", elapsed)
+	}
+}
+
+func TestModelValidateRequest(t *testing.T) {
+	model := Model{
+		ID:                  "m",
+		SupportedParameters: []string{"max_tokens", "temperature"},
+		Architecture:        ModelArchitecture{InputModalities: []string{"text"}},
+		TopProvider:         ModelTopProvider{MaxCompletionTokens: 100},
+	}
+
+	if err := model.ValidateRequest(Request{MaxTokens: 50, Temperature: 0.5}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := model.ValidateRequest(Request{
+		MaxTokens: 500,
+		Tools:     []Tool{{Type: DefaultToolType}},
+		Messages: []MessageRequest{
+			{Role: RoleUser, Content: []ContentPart{{Type: ContentTypeImage, ImageURL: &ImageURL{URL: "x"}}}},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{`"tools"`, "exceeds", "image input"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %q", want, err)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package openrouterapigo
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// ClientOption configures an OpenRouterClient.
+type ClientOption func(*OpenRouterClient)
+
+// Middleware wraps the HTTP transport used by the client, e.g. to record
+// metrics or inject headers on every attempt.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to http.RoundTripper, for writing
+// middleware inline.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// WithBaseURL sets the API base URL. Defaults to https://openrouter.ai/api/v1.
+func WithBaseURL(apiURL string) ClientOption {
+	return func(c *OpenRouterClient) {
+		c.apiURL = apiURL
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for requests. The client is not
+// modified; middleware is applied to a copy.
+func WithHTTPClient(client *http.Client) ClientOption {
+	return func(c *OpenRouterClient) {
+		c.httpClient = client
+	}
+}
+
+// WithAppAttribution sets the HTTP-Referer and X-Title headers OpenRouter uses
+// to attribute requests to your app. Empty values are not sent.
+func WithAppAttribution(refererURL string, title string) ClientOption {
+	return func(c *OpenRouterClient) {
+		if refererURL != "" {
+			c.headers.Set("HTTP-Referer", refererURL)
+		}
+		if title != "" {
+			c.headers.Set("X-Title", title)
+		}
+	}
+}
+
+// WithHeader adds a static header sent with every request.
+func WithHeader(key string, value string) ClientOption {
+	return func(c *OpenRouterClient) {
+		c.headers.Set(key, value)
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) ClientOption {
+	return WithHeader("User-Agent", userAgent)
+}
+
+// WithDefaultModel sets the model used by requests that specify neither Model
+// nor Models.
+func WithDefaultModel(model string) ClientOption {
+	return func(c *OpenRouterClient) {
+		c.defaultModel = model
+	}
+}
+
+// WithTimeout limits each HTTP attempt. Non-streaming calls must complete
+// within the timeout; streams must receive the response headers within it.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *OpenRouterClient) {
+		c.timeout = timeout
+	}
+}
+
+// WithStreamIdleTimeout fails streams with ErrStreamIdleTimeout when no bytes,
+// including keep-alive comments, arrive for the given duration. Time the
+// stream waits on a slow consumer does not count.
+func WithStreamIdleTimeout(timeout time.Duration) ClientOption {
+	return func(c *OpenRouterClient) {
+		c.streamIdleTimeout = timeout
+	}
+}
+
+// WithFirstTokenTimeout fails streams with ErrFirstTokenTimeout when the first
+// chunk does not arrive within the given duration after the request was sent.
+// Keep-alive comments do not count.
+func WithFirstTokenTimeout(timeout time.Duration) ClientOption {
+	return func(c *OpenRouterClient) {
+		c.firstTokenTimeout = timeout
+	}
+}
+
+// WithRetryPolicy sets the retry policy, see SetRetryPolicy.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *OpenRouterClient) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithLogger sets the logger used for request and retry diagnostics.
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *OpenRouterClient) {
+		c.logger = logger
+	}
+}
+
+// WithMiddleware appends transport middleware. The first middleware is the
+// outermost one, so it sees the request first and the response last.
+func WithMiddleware(middleware ...Middleware) ClientOption {
+	return func(c *OpenRouterClient) {
+		c.middleware = append(c.middleware, middleware...)
+	}
+}
+
+// applyOptions runs opts and wraps the HTTP transport with the configured
+// middleware.
+func (c *OpenRouterClient) applyOptions(opts []ClientOption) {
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if len(c.middleware) == 0 {
+		return
+	}
+
+	transport := c.httpClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		transport = c.middleware[i](transport)
+	}
+	httpClient := *c.httpClient
+	httpClient.Transport = transport
+	c.httpClient = &httpClient
+}
@@ -0,0 +1,179 @@
+package openrouterapigo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClientOptions_HeadersAndDefaultModel(t *testing.T) {
+	var gotHeaders http.Header
+	var gotRequest Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeaders = r.Header.Clone()
+		json.NewDecoder(r.Body).Decode(&gotRequest)
+		fmt.Fprint(w, `{"choices":[]}`)
+	}))
+	defer server.Close()
+
+	var middlewareCalls []string
+	record := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				middlewareCalls = append(middlewareCalls, name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+
+	client := NewOpenRouterClient("key",
+		WithBaseURL(server.URL),
+		WithHTTPClient(server.Client()),
+		WithAppAttribution("https://example.com", "Example"),
+		WithHeader("X-Team", "search"),
+		WithUserAgent("example/1.0"),
+		WithDefaultModel("default/model"),
+		WithMiddleware(record("outer"), record("inner")),
+	)
+
+	if _, err := client.FetchChatCompletions(Request{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"Authorization": "Bearer key",
+		"HTTP-Referer":  "https://example.com",
+		"X-Title":       "Example",
+		"X-Team":        "search",
+		"User-Agent":    "example/1.0",
+	}
+	for key, value := range expected {
+		if gotHeaders.Get(key) != value {
+			t.Errorf("header %s: expected %q, got %q", key, value, gotHeaders.Get(key))
+		}
+	}
+	if gotRequest.Model != "default/model" {
+		t.Errorf("expected default model, got %q", gotRequest.Model)
+	}
+	if len(middlewareCalls) != 2 || middlewareCalls[0] != "outer" || middlewareCalls[1] != "inner" {
+		t.Errorf("unexpected middleware order: %v", middlewareCalls)
+	}
+
+	if _, err := client.FetchChatCompletions(Request{Model: "explicit/model"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotRequest.Model != "explicit/model" {
+		t.Errorf("expected explicit model to win, got %q", gotRequest.Model)
+	}
+}
+
+func TestClientOptions_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client(), WithTimeout(50*time.Millisecond))
+
+	_, err := client.FetchChatCompletionsContext(context.Background(), Request{Model: "m"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestProviderPreferencesAndRequestHeaders(t *testing.T) {
+	var body map[string]json.RawMessage
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+		json.NewDecoder(r.Body).Decode(&body)
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"hi"}}]}`)
+	}))
+	defer server.Close()
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client(), WithAppAttribution("https://client.example", "Client"))
+	allowFallbacks := false
+	provider := &ProviderPreferences{
+		Order:             []string{"Anthropic", "OpenAI"},
+		Ignore:            []string{"Foo"},
+		AllowFallbacks:    &allowFallbacks,
+		RequireParameters: true,
+		DataCollection:    DataCollectionDeny,
+		Quantizations:     []string{"fp8"},
+		Sort:              ProviderSortThroughput,
+		MaxPrice:          &MaxPrice{Prompt: 1, Completion: 2},
+		SiteName:          "Legacy",
+	}
+
+	_, err := client.FetchChatCompletions(Request{
+		Model:    "m",
+		Provider: provider,
+		Headers:  http.Header{"Http-Referer": {"https://request.example"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `{"order":["Anthropic","OpenAI"],"ignore":["Foo"],"allow_fallbacks":false,"require_parameters":true,"data_collection":"deny","quantizations":["fp8"],"sort":"throughput","max_price":{"prompt":1,"completion":2}}`
+	if string(body["provider"]) != want {
+		t.Fatalf("unexpected provider object:\n got %s\nwant %s", body["provider"], want)
+	}
+	if headers.Get("HTTP-Referer") != "https://request.example" || headers.Get("X-Title") != "Legacy" {
+		t.Fatalf("unexpected attribution headers: %v", headers)
+	}
+
+	agent := NewRouterAgent(client, "m", RouterAgentConfig{Provider: &ProviderPreferences{Only: []string{"Groq"}}})
+	if _, err := agent.Chat([]MessageRequest{{Role: RoleUser, Content: TextContent("hi")}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(body["provider"]) != `{"only":["Groq"]}` {
+		t.Fatalf("agent provider preferences not sent: %s", body["provider"])
+	}
+	if headers.Get("HTTP-Referer") != "https://client.example" || headers.Get("X-Title") != "Client" {
+		t.Fatalf("expected client attribution headers, got %v", headers)
+	}
+}
+
+func TestProviderPreferencesLegacyOnly(t *testing.T) {
+	var body map[string]json.RawMessage
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, headers = nil, r.Header
+		json.NewDecoder(r.Body).Decode(&body)
+		fmt.Fprint(w, `{"choices":[{"text":"hi","message":{"role":"assistant","content":"hi"}}]}`)
+	}))
+	defer server.Close()
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client())
+	provider := &ProviderPreferences{RefererURL: "https://legacy.example", SiteName: "Legacy"}
+
+	if _, err := client.FetchChatCompletions(Request{Model: "m", Provider: provider}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := body["provider"]; ok {
+		t.Fatalf("expected no provider object, got %s", body["provider"])
+	}
+	if headers.Get("HTTP-Referer") != "https://legacy.example" || headers.Get("X-Title") != "Legacy" {
+		t.Fatalf("unexpected attribution headers: %v", headers)
+	}
+
+	if _, err := client.FetchCompletions(CompletionRequest{Model: "m", Prompt: "hi", Provider: provider}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := body["provider"]; ok {
+		t.Fatalf("expected no provider object in completion request, got %s", body["provider"])
+	}
+	if headers.Get("X-Title") != "Legacy" {
+		t.Fatalf("unexpected attribution headers: %v", headers)
+	}
+}
@@ -0,0 +1,165 @@
+package openrouterapigo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how the client retries transient failures. The zero
+// value disables retries.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// Values below 2 disable retries.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry. It doubles on every
+	// following attempt.
+	BaseDelay time.Duration
+	// MaxDelay caps the computed backoff. Zero means no cap. It does not
+	// apply to delays requested by the server through Retry-After.
+	MaxDelay time.Duration
+	// Jitter is the fraction of each delay, between 0 and 1, that is
+	// randomized to spread retries of concurrent callers.
+	Jitter float64
+	// RetryableStatus reports whether a response with the given status code
+	// should be retried. Nil means DefaultRetryableStatus.
+	RetryableStatus func(statusCode int) bool
+}
+
+// DefaultRetryPolicy returns a policy with 3 attempts and exponential backoff
+// starting at 500ms.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:     3,
+		BaseDelay:       500 * time.Millisecond,
+		MaxDelay:        10 * time.Second,
+		Jitter:          0.2,
+		RetryableStatus: DefaultRetryableStatus,
+	}
+}
+
+// DefaultRetryableStatus reports true for rate limiting (429), bad gateway
+// (502), service unavailable (503) and Cloudflare timeouts (524).
+func DefaultRetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, 524:
+		return true
+	}
+	return false
+}
+
+// permanentError marks an error that must not be retried even if it would
+// otherwise qualify, e.g. a stream failing after chunks were delivered.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e permanentError) Unwrap() error {
+	return e.err
+}
+
+func (p RetryPolicy) shouldRetry(err error) bool {
+	var permanent permanentError
+	if errors.As(err, &permanent) {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if apiErr, ok := asAPIError(err); ok {
+		retryable := p.RetryableStatus
+		if retryable == nil {
+			retryable = DefaultRetryableStatus
+		}
+		// Errors sent inside a 200 stream carry the failure only in Code.
+		return retryable(apiErr.StatusCode) || (apiErr.Code != apiErr.StatusCode && retryable(apiErr.Code))
+	}
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
+
+// backoff returns the delay before the given retry (1-based). A Retry-After
+// header on an APIError takes precedence over the computed delay.
+func (p RetryPolicy) backoff(retry int, err error) time.Duration {
+	if apiErr, ok := asAPIError(err); ok {
+		if delay, ok := parseRetryAfter(apiErr.Headers.Get("Retry-After"), time.Now()); ok {
+			return delay
+		}
+	}
+
+	delay := p.BaseDelay
+	for i := 1; i < retry && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if p.Jitter > 0 && delay > 0 {
+		jitter := time.Duration(float64(delay) * p.Jitter * rand.Float64())
+		delay = delay - time.Duration(float64(delay)*p.Jitter/2) + jitter
+	}
+	return delay
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as
+// an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		delay := date.Sub(now)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
+
+// withRetry runs attempt until it succeeds, fails with an error that is not
+// retryable or the policy runs out of attempts. Waiting between attempts is
+// cut short by ctx.
+func (c *OpenRouterClient) withRetry(ctx context.Context, attempt func() error) error {
+	policy := c.retryPolicy
+	for try := 1; ; try++ {
+		err := attempt()
+		if err == nil {
+			return nil
+		}
+		if try >= policy.MaxAttempts || !policy.shouldRetry(err) {
+			var permanent permanentError
+			if errors.As(err, &permanent) {
+				return permanent.err
+			}
+			return err
+		}
+
+		delay := policy.backoff(try, err)
+		c.logWarn(ctx, "openrouter request failed, retrying", "attempt", try, "delay", delay, "error", err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
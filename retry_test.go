@@ -0,0 +1,139 @@
+package openrouterapigo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFetchChatCompletions_RetriesTransientStatus(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `{"error":{"code":503,"message":"overloaded"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"id":"ok","choices":[{"message":{"role":"assistant","content":"hi"}}]}`)
+	}))
+	defer server.Close()
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client())
+	client.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+
+	resp, err := client.FetchChatCompletions(Request{Model: "m"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ID != "ok" || atomic.LoadInt32(&attempts) != 3 {
+		t.Fatalf("unexpected result id=%s attempts=%d", resp.ID, attempts)
+	}
+}
+
+func TestFetchChatCompletions_DoesNotRetryClientErrors(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":{"code":400,"message":"bad"}}`)
+	}))
+	defer server.Close()
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client())
+	client.SetRetryPolicy(RetryPolicy{MaxAttempts: 5, BaseDelay: time.Millisecond})
+
+	if _, err := client.FetchChatCompletions(Request{Model: "m"}); err == nil {
+		t.Fatal("expected error")
+	}
+	if atomic.LoadInt32(&attempts) != 1 {
+		t.Fatalf("expected a single attempt, got %d", attempts)
+	}
+}
+
+func TestFetchChatCompletions_DoesNotRetryBodyReadErrors(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.Header().Set("Content-Length", "100")
+		fmt.Fprint(w, `{"id":"ok",`)
+	}))
+	defer server.Close()
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client())
+	client.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+
+	if _, err := client.FetchChatCompletions(Request{Model: "m"}); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected unexpected EOF, got %v", err)
+	}
+	if atomic.LoadInt32(&attempts) != 1 {
+		t.Fatalf("expected a single attempt for a truncated 200 body, got %d", attempts)
+	}
+}
+
+func TestFetchChatCompletionsStream_RetriesBeforeFirstChunk(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, "data: {\"id\":\"chunk\"}\n\ndata: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client())
+	client.SetRetryPolicy(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	stream := client.StartChatCompletionsStream(Request{Model: "m", Stream: true}, ctx)
+	ids := []string{}
+	for {
+		ev, ok := stream.Recv(ctx)
+		if ev.Err != nil {
+			t.Fatalf("unexpected error: %v", ev.Err)
+		}
+		if !ok {
+			break
+		}
+		if ev.Response != nil {
+			ids = append(ids, ev.Response.ID)
+		}
+	}
+	if len(ids) != 1 || ids[0] != "chunk" {
+		t.Fatalf("unexpected chunks: %v", ids)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if d, ok := parseRetryAfter("7", now); !ok || d != 7*time.Second {
+		t.Fatalf("unexpected seconds parse: %v %v", d, ok)
+	}
+	if d, ok := parseRetryAfter(now.Add(3*time.Second).Format(http.TimeFormat), now); !ok || d != 3*time.Second {
+		t.Fatalf("unexpected date parse: %v %v", d, ok)
+	}
+	if _, ok := parseRetryAfter("soon", now); ok {
+		t.Fatal("expected invalid value to be rejected")
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond}
+	for i, want := range expected {
+		if got := policy.backoff(i+1, fmt.Errorf("boom")); got != want {
+			t.Fatalf("retry %d: expected %v, got %v", i+1, want, got)
+		}
+	}
+}
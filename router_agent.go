@@ -3,27 +3,32 @@ package openrouterapigo
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"image"
 )
 
 type RouterAgentConfig struct {
-	ResponseFormat    *ResponseFormat `json:"response_format,omitempty"`
-	Stop              []string        `json:"stop,omitempty"`
-	MaxTokens         int             `json:"max_tokens,omitempty"`
-	Temperature       float64         `json:"temperature,omitempty"`
-	Tools             []Tool          `json:"tools,omitempty"`
-	ToolChoice        *ToolChoice     `json:"tool_choice,omitempty"`
-	Seed              int             `json:"seed,omitempty"`
-	TopP              float64         `json:"top_p,omitempty"`
-	TopK              int             `json:"top_k,omitempty"`
-	FrequencyPenalty  float64         `json:"frequency_penalty,omitempty"`
-	PresencePenalty   float64         `json:"presence_penalty,omitempty"`
-	RepetitionPenalty float64         `json:"repetition_penalty,omitempty"`
-	LogitBias         map[int]float64 `json:"logit_bias,omitempty"`
-	TopLogprobs       int             `json:"top_logprobs,omitempty"`
-	MinP              float64         `json:"min_p,omitempty"`
-	TopA              float64         `json:"top_a,omitempty"`
+	ResponseFormat    *ResponseFormat  `json:"response_format,omitempty"`
+	Stop              []string         `json:"stop,omitempty"`
+	MaxTokens         int              `json:"max_tokens,omitempty"`
+	Temperature       float64          `json:"temperature,omitempty"`
+	Tools             []Tool           `json:"tools,omitempty"`
+	ToolChoice        *ToolChoice      `json:"tool_choice,omitempty"`
+	Seed              int              `json:"seed,omitempty"`
+	TopP              float64          `json:"top_p,omitempty"`
+	TopK              int              `json:"top_k,omitempty"`
+	FrequencyPenalty  float64          `json:"frequency_penalty,omitempty"`
+	PresencePenalty   float64          `json:"presence_penalty,omitempty"`
+	RepetitionPenalty float64          `json:"repetition_penalty,omitempty"`
+	LogitBias         map[int]float64  `json:"logit_bias,omitempty"`
+	TopLogprobs       int              `json:"top_logprobs,omitempty"`
+	MinP              float64          `json:"min_p,omitempty"`
+	TopA              float64          `json:"top_a,omitempty"`
+	Usage             *UsageConfig     `json:"usage,omitempty"`
+	Reasoning         *ReasoningConfig `json:"reasoning,omitempty"`
+	// Provider sets the provider routing preferences of every request.
+	Provider *ProviderPreferences `json:"provider,omitempty"`
 }
 
 type RouterAgent struct {
@@ -40,9 +45,10 @@ func NewRouterAgent(client *OpenRouterClient, model string, config RouterAgentCo
 	}
 }
 
-func (agent RouterAgent) Completion(prompt string) (*Response, error) {
-	request := Request{
-		Prompt:            prompt,
+// newRequest builds a request from the agent model and config. Callers fill in
+// the messages or prompt.
+func (agent RouterAgent) newRequest(stream bool) Request {
+	return Request{
 		Model:             agent.model,
 		ResponseFormat:    agent.config.ResponseFormat,
 		Stop:              agent.config.Stop,
@@ -60,22 +66,22 @@ func (agent RouterAgent) Completion(prompt string) (*Response, error) {
 		TopLogprobs:       agent.config.TopLogprobs,
 		MinP:              agent.config.MinP,
 		TopA:              agent.config.TopA,
-		Stream:            false,
+		Usage:             agent.config.Usage,
+		Reasoning:         agent.config.Reasoning,
+		Provider:          agent.config.Provider,
+		Stream:            stream,
 	}
-
-	return agent.client.FetchChatCompletions(request)
 }
 
-func (agent RouterAgent) CompletionStream(prompt string, outputChan chan Response, processingChan chan interface{}, errChan chan error, ctx context.Context) {
-	request := Request{
+// newCompletionRequest builds a legacy text completion request from the agent
+// model and the config fields that endpoint supports.
+func (agent RouterAgent) newCompletionRequest(prompt string, stream bool) CompletionRequest {
+	return CompletionRequest{
 		Prompt:            prompt,
 		Model:             agent.model,
-		ResponseFormat:    agent.config.ResponseFormat,
 		Stop:              agent.config.Stop,
 		MaxTokens:         agent.config.MaxTokens,
 		Temperature:       agent.config.Temperature,
-		Tools:             agent.config.Tools,
-		ToolChoice:        agent.config.ToolChoice,
 		Seed:              agent.config.Seed,
 		TopP:              agent.config.TopP,
 		TopK:              agent.config.TopK,
@@ -83,81 +89,83 @@ func (agent RouterAgent) CompletionStream(prompt string, outputChan chan Respons
 		PresencePenalty:   agent.config.PresencePenalty,
 		RepetitionPenalty: agent.config.RepetitionPenalty,
 		LogitBias:         agent.config.LogitBias,
-		TopLogprobs:       agent.config.TopLogprobs,
 		MinP:              agent.config.MinP,
 		TopA:              agent.config.TopA,
-		Stream:            true,
+		Usage:             agent.config.Usage,
+		Provider:          agent.config.Provider,
+		Stream:            stream,
 	}
+}
 
-	agent.client.FetchChatCompletionsStream(request, outputChan, processingChan, errChan, ctx)
+// Completion sends a raw prompt to the legacy text completions endpoint.
+// Use Chat for chat models.
+func (agent RouterAgent) Completion(prompt string) (*CompletionResponse, error) {
+	return agent.CompletionContext(context.Background(), prompt)
+}
+
+// CompletionContext is like Completion but aborts the request when ctx is done.
+func (agent RouterAgent) CompletionContext(ctx context.Context, prompt string) (*CompletionResponse, error) {
+	return agent.client.FetchCompletionsContext(ctx, agent.newCompletionRequest(prompt, false))
+}
+
+func (agent RouterAgent) CompletionStream(prompt string, outputChan chan CompletionResponse, processingChan chan interface{}, errChan chan error, ctx context.Context) {
+	agent.client.FetchCompletionsStream(agent.newCompletionRequest(prompt, true), outputChan, processingChan, errChan, ctx)
 }
 
 func (agent RouterAgent) Chat(messages []MessageRequest) (*Response, error) {
-	request := Request{
-		Messages:          messages,
-		Model:             agent.model,
-		ResponseFormat:    agent.config.ResponseFormat,
-		Stop:              agent.config.Stop,
-		MaxTokens:         agent.config.MaxTokens,
-		Temperature:       agent.config.Temperature,
-		Tools:             agent.config.Tools,
-		ToolChoice:        agent.config.ToolChoice,
-		Seed:              agent.config.Seed,
-		TopP:              agent.config.TopP,
-		TopK:              agent.config.TopK,
-		FrequencyPenalty:  agent.config.FrequencyPenalty,
-		PresencePenalty:   agent.config.PresencePenalty,
-		RepetitionPenalty: agent.config.RepetitionPenalty,
-		LogitBias:         agent.config.LogitBias,
-		TopLogprobs:       agent.config.TopLogprobs,
-		MinP:              agent.config.MinP,
-		TopA:              agent.config.TopA,
-		Stream:            false,
-	}
+	return agent.ChatContext(context.Background(), messages)
+}
 
-	return agent.client.FetchChatCompletions(request)
+// ChatContext is like Chat but aborts the request when ctx is done.
+func (agent RouterAgent) ChatContext(ctx context.Context, messages []MessageRequest) (*Response, error) {
+	request := agent.newRequest(false)
+	request.Messages = messages
+
+	return agent.client.FetchChatCompletionsContext(ctx, request)
 }
 
 func (agent RouterAgent) ChatStream(messages []MessageRequest, outputChan chan Response, processingChan chan interface{}, errChan chan error, ctx context.Context) {
-	request := Request{
-		Messages:          messages,
-		Model:             agent.model,
-		ResponseFormat:    agent.config.ResponseFormat,
-		Stop:              agent.config.Stop,
-		MaxTokens:         agent.config.MaxTokens,
-		Temperature:       agent.config.Temperature,
-		Tools:             agent.config.Tools,
-		ToolChoice:        agent.config.ToolChoice,
-		Seed:              agent.config.Seed,
-		TopP:              agent.config.TopP,
-		TopK:              agent.config.TopK,
-		FrequencyPenalty:  agent.config.FrequencyPenalty,
-		PresencePenalty:   agent.config.PresencePenalty,
-		RepetitionPenalty: agent.config.RepetitionPenalty,
-		LogitBias:         agent.config.LogitBias,
-		TopLogprobs:       agent.config.TopLogprobs,
-		MinP:              agent.config.MinP,
-		TopA:              agent.config.TopA,
-		Stream:            true,
-	}
+	request := agent.newRequest(true)
+	request.Messages = messages
 
 	agent.client.FetchChatCompletionsStream(request, outputChan, processingChan, errChan, ctx)
 }
 
+// StartChatStream starts a streaming chat completion for messages. The
+// returned stream also reports its timing with Stats.
+func (agent RouterAgent) StartChatStream(messages []MessageRequest, ctx context.Context) *ChatCompletionsStream {
+	request := agent.newRequest(true)
+	request.Messages = messages
+
+	return agent.client.StartChatCompletionsStream(request, ctx)
+}
+
 type message interface {
 	GetRole() MessageRole
 	GetContentPart() []ContentPart
 	GetToolCalls() []ToolCall
 	GetReasoning() string
+	GetReasoningDetails() []ReasoningDetail
 	GetToolCallId() string
 	GetName() string
 }
 
+// DefaultMaxToolCorrections is the MaxToolCorrections set by
+// NewRouterAgentChat.
+const DefaultMaxToolCorrections = 3
+
+// ErrTooManyToolCorrections is returned when the model keeps calling tools with
+// invalid arguments for more than MaxToolCorrections rounds in a row.
+var ErrTooManyToolCorrections = errors.New("openrouter: too many tool argument corrections")
+
 type RouterAgentChat struct {
 	RouterAgent
 	Messages     []message
 	ToolRegistry ToolRegistry
 	ChoiceSelector
+	// MaxToolCorrections caps the consecutive rounds in which the model may
+	// retry tool calls rejected for invalid arguments. Zero means no cap.
+	MaxToolCorrections int
 }
 
 type ChoiceSelector func([]Choice) (Choice, error)
@@ -182,8 +190,9 @@ func NewRouterAgentChat(client *OpenRouterClient, model string, config RouterAge
 				Content: TextContent(system_prompt),
 			},
 		},
-		ToolRegistry:   *NewToolRegistry(),
-		ChoiceSelector: defaultChoiceSelector,
+		ToolRegistry:       *NewToolRegistry(),
+		ChoiceSelector:     defaultChoiceSelector,
+		MaxToolCorrections: DefaultMaxToolCorrections,
 	}
 }
 
@@ -193,6 +202,14 @@ func AddToolToAgent[T any](agent *RouterAgentChat, definition ToolDefinition[T])
 	})
 }
 
+// AddContextToolToAgent registers a tool that receives the context of the chat
+// call and may return an error, see ContextToolDefinition.
+func AddContextToolToAgent[T any, R any](agent *RouterAgentChat, definition ContextToolDefinition[T, R]) error {
+	return agent.ToolRegistry.Register(contextToolWrapper[T, R]{
+		definition: definition,
+	})
+}
+
 func generateMessagesForRequest(messages []message) []MessageRequest {
 	newMessages := make([]MessageRequest, 0, len(messages))
 	for _, msg := range messages {
@@ -208,7 +225,8 @@ func generateMessagesForRequest(messages []message) []MessageRequest {
 				Content:    parts,
 				ToolCallID: msg.GetToolCallId(),
 				// Name:       msg.GetName(),
-				ToolCalls: msg.GetToolCalls(),
+				ToolCalls:        msg.GetToolCalls(),
+				ReasoningDetails: msg.GetReasoningDetails(),
 			})
 		} else {
 			newMessages = append(newMessages, MessageRequest{
@@ -216,26 +234,53 @@ func generateMessagesForRequest(messages []message) []MessageRequest {
 				Content:    parts,
 				ToolCallID: msg.GetToolCallId(),
 				// Name:       msg.GetName(),
-				ToolCalls: msg.GetToolCalls(),
+				ToolCalls:        msg.GetToolCalls(),
+				ReasoningDetails: msg.GetReasoningDetails(),
 			})
 		}
 	}
 	return newMessages
 }
 
-func (agent *RouterAgentChat) callTools(toolCalls []ToolCall) ([]message, error) {
-	newMessages := make([]message, 0)
+// toolErrorOutput is the tool message sent to the model when a tool call
+// fails. Invalid arguments are detailed in ValidationErrors so the model can
+// correct the call.
+type toolErrorOutput struct {
+	Err              string             `json:"error"`
+	ValidationErrors []*ValidationError `json:"validation_errors,omitempty"`
+}
+
+// callTools runs the requested tools and returns their results as tool
+// messages. A non-nil handler receives a started and a completed event per
+// call. argsErr is the last *ToolArgumentsError of the round, if any.
+func (agent *RouterAgentChat) callTools(ctx context.Context, toolCalls []ToolCall, handler func(ChatStreamEvent)) (newMessages []message, argsErr error, err error) {
+	newMessages = make([]message, 0)
 	for _, tool := range toolCalls {
-		toolOutput, err := agent.ToolRegistry.CallTool(tool.Function.Name, json.RawMessage(tool.Function.Arguments))
-		type errorOutput struct {
-			Err string `json:"error"`
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		if handler != nil {
+			call := tool
+			handler(ChatStreamEvent{Type: ChatEventToolCallStarted, ToolCall: &call})
 		}
+		toolOutput, err := agent.ToolRegistry.CallToolContext(ctx, tool.Function.Name, json.RawMessage(tool.Function.Arguments))
 		if err != nil {
-			toolOutputByte, _ := json.Marshal(errorOutput{
-				Err: fmt.Sprintf("%s", err),
-			})
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, nil, ctxErr
+			}
+			output := toolErrorOutput{Err: fmt.Sprintf("%s", err)}
+			var toolArgsErr *ToolArgumentsError
+			if errors.As(err, &toolArgsErr) {
+				output.ValidationErrors = toolArgsErr.Errors
+				argsErr = toolArgsErr
+			}
+			toolOutputByte, _ := json.Marshal(output)
 			toolOutput = string(toolOutputByte)
 		}
+		if handler != nil {
+			call := tool
+			handler(ChatStreamEvent{Type: ChatEventToolCallCompleted, ToolCall: &call, ToolResult: toolOutput, ToolErr: err})
+		}
 		newMessages = append(newMessages, MessageRequest{
 			Role: RoleTool,
 			Content: []ContentPart{
@@ -248,66 +293,63 @@ func (agent *RouterAgentChat) callTools(toolCalls []ToolCall) ([]message, error)
 			Name:       tool.Function.Name,
 		})
 	}
-	return newMessages, nil
+	return newMessages, argsErr, nil
 }
 
-func (agent *RouterAgentChat) Chat(messageInput string) ([]message, error) {
-	newMessages := make([]message, 0)
-	newMessages = append(newMessages, MessageRequest{
-		Role:    RoleUser,
-		Content: TextContent(messageInput),
-	})
+// toolLoopOptions customizes runToolLoop. The zero value runs the regular
+// non-streaming loop with the agent config.
+type toolLoopOptions struct {
+	// handler makes every round stream and receives its events.
+	handler func(ChatStreamEvent)
+	// responseFormat overrides the response format of the agent config.
+	responseFormat *ResponseFormat
+}
+
+// runToolLoop sends the conversation until the model stops requesting tools.
+// The new messages are appended to agent.Messages only when the loop succeeds.
+func (agent *RouterAgentChat) runToolLoop(ctx context.Context, newMessages []message, options toolLoopOptions) ([]message, error) {
+	handler := options.handler
+	corrections := 0
 	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
 		tools, err := agent.ToolRegistry.GenerateTools()
 		if err != nil {
 			return nil, fmt.Errorf("error while generating tools: %s", err)
 		}
 
-		request := Request{
-			Messages:          append(generateMessagesForRequest(agent.Messages), generateMessagesForRequest(newMessages)...),
-			Model:             agent.model,
-			ResponseFormat:    agent.config.ResponseFormat,
-			Stop:              agent.config.Stop,
-			MaxTokens:         agent.config.MaxTokens,
-			Temperature:       agent.config.Temperature,
-			Tools:             tools,
-			ToolChoice:        agent.config.ToolChoice,
-			Seed:              agent.config.Seed,
-			TopP:              agent.config.TopP,
-			TopK:              agent.config.TopK,
-			FrequencyPenalty:  agent.config.FrequencyPenalty,
-			PresencePenalty:   agent.config.PresencePenalty,
-			RepetitionPenalty: agent.config.RepetitionPenalty,
-			LogitBias:         agent.config.LogitBias,
-			TopLogprobs:       agent.config.TopLogprobs,
-			MinP:              agent.config.MinP,
-			TopA:              agent.config.TopA,
-			Stream:            false,
+		request := agent.newRequest(handler != nil)
+		request.Messages = append(generateMessagesForRequest(agent.Messages), generateMessagesForRequest(newMessages)...)
+		request.Tools = tools
+		if options.responseFormat != nil {
+			request.ResponseFormat = options.responseFormat
 		}
 
-		response, err := agent.client.FetchChatCompletions(request)
-
-		if err != nil {
-			return nil, err
+		var responseMessage *MessageResponse
+		if handler != nil {
+			responseMessage, err = agent.streamTurn(ctx, request, handler)
+		} else {
+			responseMessage, err = agent.fetchTurn(ctx, request)
 		}
-
-		selectedChoice, err := agent.ChoiceSelector(response.Choices)
 		if err != nil {
 			return nil, err
 		}
 
-		if selectedChoice.Message == nil {
-			return nil, fmt.Errorf("missing message in selected choice")
-		}
-
-		newMessages = append(newMessages, selectedChoice.Message)
+		newMessages = append(newMessages, responseMessage)
 
-		toolMessages, err := agent.callTools(selectedChoice.Message.ToolCalls)
+		toolMessages, argsErr, err := agent.callTools(ctx, responseMessage.ToolCalls, handler)
 		if err != nil {
 			return nil, err
 		}
+		if argsErr == nil {
+			corrections = 0
+		} else if corrections++; agent.MaxToolCorrections > 0 && corrections > agent.MaxToolCorrections {
+			return nil, fmt.Errorf("%w: %w", ErrTooManyToolCorrections, argsErr)
+		}
 		newMessages = append(newMessages, toolMessages...)
-		if len(selectedChoice.Message.ToolCalls) == 0 {
+		if len(responseMessage.ToolCalls) == 0 {
 			break
 		}
 	}
@@ -317,8 +359,47 @@ func (agent *RouterAgentChat) Chat(messageInput string) ([]message, error) {
 	return newMessages, nil
 }
 
+// fetchTurn sends one round of the tool loop and returns the message of the
+// choice picked by the ChoiceSelector.
+func (agent *RouterAgentChat) fetchTurn(ctx context.Context, request Request) (*MessageResponse, error) {
+	response, err := agent.client.FetchChatCompletionsContext(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	selectedChoice, err := agent.ChoiceSelector(response.Choices)
+	if err != nil {
+		return nil, err
+	}
+
+	if selectedChoice.Message == nil {
+		return nil, fmt.Errorf("missing message in selected choice")
+	}
+	return selectedChoice.Message, nil
+}
+
+func (agent *RouterAgentChat) Chat(messageInput string) ([]message, error) {
+	return agent.ChatContext(context.Background(), messageInput)
+}
+
+// ChatContext is like Chat but stops the tool loop when ctx is done. The
+// context is also passed to the running tools.
+func (agent *RouterAgentChat) ChatContext(ctx context.Context, messageInput string) ([]message, error) {
+	newMessages := make([]message, 0)
+	newMessages = append(newMessages, MessageRequest{
+		Role:    RoleUser,
+		Content: TextContent(messageInput),
+	})
+	return agent.runToolLoop(ctx, newMessages, toolLoopOptions{})
+}
+
 // https://openrouter.ai/docs/features/images-and-pdfs
 func (agent *RouterAgentChat) ChatWithImage(messageString string, imgs ...image.Image) ([]message, error) {
+	return agent.ChatWithImageContext(context.Background(), messageString, imgs...)
+}
+
+// ChatWithImageContext is like ChatWithImage but stops the tool loop when ctx is done.
+func (agent *RouterAgentChat) ChatWithImageContext(ctx context.Context, messageString string, imgs ...image.Image) ([]message, error) {
 	contentList, err := buildImageContent(messageString, imgs)
 	if err != nil {
 		return nil, err
@@ -330,67 +411,15 @@ func (agent *RouterAgentChat) ChatWithImage(messageString string, imgs ...image.
 			Role:    RoleUser,
 			Content: contentList,
 		})
-	for {
-		tools, err := agent.ToolRegistry.GenerateTools()
-		if err != nil {
-			return nil, fmt.Errorf("error while generating tools: %s", err)
-		}
-
-		request := Request{
-			Messages:          append(generateMessagesForRequest(agent.Messages), generateMessagesForRequest(newMessages)...),
-			Model:             agent.model,
-			ResponseFormat:    agent.config.ResponseFormat,
-			Stop:              agent.config.Stop,
-			MaxTokens:         agent.config.MaxTokens,
-			Temperature:       agent.config.Temperature,
-			Tools:             tools,
-			ToolChoice:        agent.config.ToolChoice,
-			Seed:              agent.config.Seed,
-			TopP:              agent.config.TopP,
-			TopK:              agent.config.TopK,
-			FrequencyPenalty:  agent.config.FrequencyPenalty,
-			PresencePenalty:   agent.config.PresencePenalty,
-			RepetitionPenalty: agent.config.RepetitionPenalty,
-			LogitBias:         agent.config.LogitBias,
-			TopLogprobs:       agent.config.TopLogprobs,
-			MinP:              agent.config.MinP,
-			TopA:              agent.config.TopA,
-			Stream:            false,
-		}
-
-		response, err := agent.client.FetchChatCompletions(request)
-
-		if err != nil {
-			return nil, err
-		}
-
-		selectedChoice, err := agent.ChoiceSelector(response.Choices)
-		if err != nil {
-			return nil, err
-		}
-
-		if selectedChoice.Message == nil {
-			return nil, fmt.Errorf("missing message in selected choice")
-		}
-
-		newMessages = append(newMessages, selectedChoice.Message)
-
-		toolMessages, err := agent.callTools(selectedChoice.Message.ToolCalls)
-		if err != nil {
-			return nil, err
-		}
-		newMessages = append(newMessages, toolMessages...)
-		if len(selectedChoice.Message.ToolCalls) == 0 {
-			break
-		}
-	}
-
-	agent.Messages = append(agent.Messages, newMessages...)
-
-	return newMessages, nil
+	return agent.runToolLoop(ctx, newMessages, toolLoopOptions{})
 }
 
 func (agent *RouterAgentChat) ChatWithPDF(messageString string, pathsToPdf ...string) ([]message, error) {
+	return agent.ChatWithPDFContext(context.Background(), messageString, pathsToPdf...)
+}
+
+// ChatWithPDFContext is like ChatWithPDF but stops the tool loop when ctx is done.
+func (agent *RouterAgentChat) ChatWithPDFContext(ctx context.Context, messageString string, pathsToPdf ...string) ([]message, error) {
 	contentList, err := buildPDFContent(messageString, pathsToPdf)
 	if err != nil {
 		return nil, err
@@ -402,62 +431,5 @@ func (agent *RouterAgentChat) ChatWithPDF(messageString string, pathsToPdf ...st
 			Role:    RoleUser,
 			Content: contentList,
 		})
-	for {
-		tools, err := agent.ToolRegistry.GenerateTools()
-		if err != nil {
-			return nil, fmt.Errorf("error while generating tools: %s", err)
-		}
-
-		request := Request{
-			Messages:          append(generateMessagesForRequest(agent.Messages), generateMessagesForRequest(newMessages)...),
-			Model:             agent.model,
-			ResponseFormat:    agent.config.ResponseFormat,
-			Stop:              agent.config.Stop,
-			MaxTokens:         agent.config.MaxTokens,
-			Temperature:       agent.config.Temperature,
-			Tools:             tools,
-			ToolChoice:        agent.config.ToolChoice,
-			Seed:              agent.config.Seed,
-			TopP:              agent.config.TopP,
-			TopK:              agent.config.TopK,
-			FrequencyPenalty:  agent.config.FrequencyPenalty,
-			PresencePenalty:   agent.config.PresencePenalty,
-			RepetitionPenalty: agent.config.RepetitionPenalty,
-			LogitBias:         agent.config.LogitBias,
-			TopLogprobs:       agent.config.TopLogprobs,
-			MinP:              agent.config.MinP,
-			TopA:              agent.config.TopA,
-			Stream:            false,
-		}
-
-		response, err := agent.client.FetchChatCompletions(request)
-
-		if err != nil {
-			return nil, err
-		}
-
-		selectedChoice, err := agent.ChoiceSelector(response.Choices)
-		if err != nil {
-			return nil, err
-		}
-
-		if selectedChoice.Message == nil {
-			return nil, fmt.Errorf("missing message in selected choice")
-		}
-
-		newMessages = append(newMessages, selectedChoice.Message)
-
-		toolMessages, err := agent.callTools(selectedChoice.Message.ToolCalls)
-		if err != nil {
-			return nil, err
-		}
-		newMessages = append(newMessages, toolMessages...)
-		if len(selectedChoice.Message.ToolCalls) == 0 {
-			break
-		}
-	}
-
-	agent.Messages = append(agent.Messages, newMessages...)
-
-	return newMessages, nil
+	return agent.runToolLoop(ctx, newMessages, toolLoopOptions{})
 }
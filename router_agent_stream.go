@@ -0,0 +1,108 @@
+package openrouterapigo
+
+import (
+	"context"
+)
+
+type ChatStreamEventType string
+
+const (
+	// ChatEventTextDelta carries a piece of the assistant content in Text.
+	ChatEventTextDelta ChatStreamEventType = "text_delta"
+	// ChatEventReasoningDelta carries a piece of the model reasoning in Text.
+	ChatEventReasoningDelta ChatStreamEventType = "reasoning_delta"
+	// ChatEventToolCallStarted is sent right before a tool is invoked.
+	ChatEventToolCallStarted ChatStreamEventType = "tool_call_started"
+	// ChatEventToolCallCompleted is sent after a tool returned, with its
+	// result in ToolResult.
+	ChatEventToolCallCompleted ChatStreamEventType = "tool_call_completed"
+	// ChatEventTurnFinished is sent when a model response is complete, with
+	// the assembled message in Message.
+	ChatEventTurnFinished ChatStreamEventType = "turn_finished"
+	// ChatEventUsage carries the token usage of a turn in Usage.
+	ChatEventUsage ChatStreamEventType = "usage"
+)
+
+// ChatStreamEvent is passed to the handler of StreamChat. Which fields are set
+// depends on Type.
+type ChatStreamEvent struct {
+	Type ChatStreamEventType
+	// Text is the content or reasoning delta.
+	Text string
+	// ToolCall is the tool call being run.
+	ToolCall *ToolCall
+	// ToolResult is the payload sent back to the model for the tool call. When
+	// the tool failed it is the error payload and ToolErr is set.
+	ToolResult string
+	ToolErr    error
+	// Message is the complete assistant message of the finished turn.
+	Message *MessageResponse
+	// FinishReason is the finish reason of the finished turn.
+	FinishReason string
+	// Usage is the token usage of the turn.
+	Usage *ResponseUsage
+	// Stats is the timing of the finished turn's stream.
+	Stats *StreamStats
+}
+
+// StreamChat is like ChatContext but streams every round of the tool loop,
+// reporting deltas, tool calls and finished turns to handler as they happen.
+// The handler is called from the calling goroutine. Streamed rounds always use
+// the first choice; the ChoiceSelector is not consulted.
+func (agent *RouterAgentChat) StreamChat(ctx context.Context, messageInput string, handler func(ChatStreamEvent)) ([]message, error) {
+	if handler == nil {
+		handler = func(ChatStreamEvent) {}
+	}
+	newMessages := make([]message, 0)
+	newMessages = append(newMessages, MessageRequest{
+		Role:    RoleUser,
+		Content: TextContent(messageInput),
+	})
+	return agent.runToolLoop(ctx, newMessages, toolLoopOptions{handler: handler})
+}
+
+// streamTurn streams one round of the tool loop and returns the assembled
+// message.
+func (agent *RouterAgentChat) streamTurn(ctx context.Context, request Request, handler func(ChatStreamEvent)) (*MessageResponse, error) {
+	stream := agent.client.StartChatCompletionsStream(request, ctx)
+	defer stream.Close()
+	accumulator := NewStreamAccumulator()
+	for {
+		event, ok := stream.Recv(ctx)
+		if event.Err != nil {
+			return nil, event.Err
+		}
+		if !ok {
+			break
+		}
+		if event.Response == nil {
+			continue
+		}
+
+		accumulator.Add(*event.Response)
+		if len(event.Response.Choices) == 0 || event.Response.Choices[0].Delta == nil {
+			continue
+		}
+		delta := event.Response.Choices[0].Delta
+		if delta.Reasoning != "" {
+			handler(ChatStreamEvent{Type: ChatEventReasoningDelta, Text: delta.Reasoning})
+		}
+		if delta.Content != "" {
+			handler(ChatStreamEvent{Type: ChatEventTextDelta, Text: delta.Content})
+		}
+	}
+
+	message := accumulator.Message()
+	stats := stream.Stats()
+	if usage := accumulator.Usage(); usage != nil {
+		handler(ChatStreamEvent{Type: ChatEventUsage, Usage: usage})
+	}
+	handler(ChatStreamEvent{
+		Type:         ChatEventTurnFinished,
+		Message:      &message,
+		FinishReason: accumulator.FinishReason(),
+		Usage:        accumulator.Usage(),
+		Stats:        &stats,
+	})
+	return &message, nil
+}
@@ -0,0 +1,100 @@
+package openrouterapigo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRouterAgentChat_StreamChat(t *testing.T) {
+	var requests []Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := Request{}
+		json.NewDecoder(r.Body).Decode(&request)
+		requests = append(requests, request)
+		if len(requests) == 1 {
+			fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"role\":\"assistant\",\"reasoning\":\"need weather\"}}]}\n\n")
+			fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"\",\"tool_calls\":[{\"index\":0,\"id\":\"call_1\",\"type\":\"function\",\"function\":{\"name\":\"weather\",\"arguments\":\"{\\\"city\\\":\"}}]}}]}\n\n")
+			fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"\",\"tool_calls\":[{\"index\":0,\"function\":{\"arguments\":\"\\\"Oslo\\\"}\"}}]}}]}\n\n")
+			fmt.Fprint(w, "data: {\"choices\":[{\"finish_reason\":\"tool_calls\",\"delta\":{\"content\":\"\"}}]}\n\ndata: [DONE]\n\n")
+			return
+		}
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"role\":\"assistant\",\"content\":\"Sunny \"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"in Oslo\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"finish_reason\":\"stop\",\"delta\":{\"content\":\"\"}}],\"usage\":{\"prompt_tokens\":10,\"completion_tokens\":3,\"total_tokens\":13}}\n\ndata: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client())
+	agent := NewRouterAgentChat(client, "m", RouterAgentConfig{}, "system")
+	type args struct {
+		City string `json:"city"`
+	}
+	err := AddToolToAgent(&agent, ToolDefinition[args]{
+		Name: "weather",
+		Function: func(a args) any {
+			return "sunny in " + a.City
+		},
+	})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	var events []string
+	text := ""
+	var toolResult string
+	var usage *ResponseUsage
+	newMessages, err := agent.StreamChat(ctx, "weather?", func(event ChatStreamEvent) {
+		events = append(events, string(event.Type))
+		switch event.Type {
+		case ChatEventTextDelta:
+			text += event.Text
+		case ChatEventToolCallStarted:
+			if event.ToolCall.Function.Arguments != `{"city":"Oslo"}` {
+				t.Errorf("tool call started with partial arguments: %+v", event.ToolCall)
+			}
+		case ChatEventToolCallCompleted:
+			toolResult = event.ToolResult
+		case ChatEventUsage:
+			usage = event.Usage
+		}
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "reasoning_delta turn_finished tool_call_started tool_call_completed text_delta text_delta usage turn_finished"
+	if strings.Join(events, " ") != want {
+		t.Fatalf("unexpected events:\n got %s\nwant %s", strings.Join(events, " "), want)
+	}
+	if text != "Sunny in Oslo" || toolResult != `"sunny in Oslo"` {
+		t.Fatalf("unexpected text or tool result: %q %q", text, toolResult)
+	}
+	if usage == nil || usage.TotalTokens != 13 {
+		t.Fatalf("unexpected usage: %+v", usage)
+	}
+
+	if len(requests) != 2 || !requests[0].Stream || !requests[1].Stream {
+		t.Fatalf("expected two streamed requests, got %+v", requests)
+	}
+	second := requests[1].Messages
+	if len(second) != 4 || second[2].ToolCalls[0].Function.Arguments != `{"city":"Oslo"}` || second[3].ToolCallID != "call_1" {
+		t.Fatalf("unexpected second round messages: %+v", second)
+	}
+
+	// user, assistant tool call, tool result, final assistant
+	if len(newMessages) != 4 || len(agent.Messages) != 5 {
+		t.Fatalf("unexpected history: %d new, %d total", len(newMessages), len(agent.Messages))
+	}
+	if final := newMessages[3].GetContentPart(); final[0].Text != "Sunny in Oslo" {
+		t.Fatalf("unexpected final message: %+v", final)
+	}
+}
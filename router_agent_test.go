@@ -1,7 +1,12 @@
 package openrouterapigo
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 )
@@ -59,3 +64,209 @@ func TestGenerateMessagesForRequest_OmitsContentWhenToolCalls(t *testing.T) {
 		t.Fatalf("tool_calls must be present, got: %s", jsonStr)
 	}
 }
+
+type cancellingTool struct {
+	cancel context.CancelFunc
+	seen   context.Context
+}
+
+func (c *cancellingTool) Call(args json.RawMessage) (any, error) {
+	return nil, errors.New("CallContext expected")
+}
+
+func (c *cancellingTool) CallContext(ctx context.Context, args json.RawMessage) (any, error) {
+	c.seen = ctx
+	c.cancel()
+	return nil, ctx.Err()
+}
+
+func (c *cancellingTool) Metadata() FunctionDescription {
+	return FunctionDescription{Name: "cancel", Parameters: map[string]interface{}{}}
+}
+
+func TestRouterAgentChat_ChatContextCancelledInTool(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"","tool_calls":[{"id":"1","type":"function","function":{"name":"cancel","arguments":"{}"}}]}}]}`)
+	}))
+	defer server.Close()
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client())
+	agent := NewRouterAgentChat(client, "m", RouterAgentConfig{}, "system")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tool := &cancellingTool{cancel: cancel}
+	if err := agent.ToolRegistry.Register(tool); err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+
+	_, err := agent.ChatContext(ctx, "hi")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+	if tool.seen == nil {
+		t.Fatal("tool did not receive the chat context")
+	}
+	if requests != 1 {
+		t.Fatalf("expected loop to stop after first round, got %d requests", requests)
+	}
+	if len(agent.Messages) != 1 {
+		t.Fatalf("expected history to stay untouched, got %d messages", len(agent.Messages))
+	}
+}
+
+func TestRouterAgentChat_ReasoningDetailsRoundTrip(t *testing.T) {
+	var requests []map[string]json.RawMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := map[string]json.RawMessage{}
+		json.NewDecoder(r.Body).Decode(&request)
+		requests = append(requests, request)
+		if len(requests) == 1 {
+			fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"","reasoning_details":[{"type":"reasoning.encrypted","id":"r1","data":"secret"}],"tool_calls":[{"id":"1","type":"function","function":{"name":"noop","arguments":"{}"}}]}}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"done"}}]}`)
+	}))
+	defer server.Close()
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client())
+	agent := NewRouterAgentChat(client, "m", RouterAgentConfig{
+		Reasoning: &ReasoningConfig{Effort: ReasoningEffortHigh, Exclude: true},
+	}, "system")
+	type args struct{}
+	if err := AddToolToAgent(&agent, ToolDefinition[args]{Name: "noop", Function: func(args) any { return "ok" }}); err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+
+	if _, err := agent.Chat("hi"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(requests))
+	}
+	if string(requests[0]["reasoning"]) != `{"effort":"high","exclude":true}` {
+		t.Fatalf("unexpected reasoning config: %s", requests[0]["reasoning"])
+	}
+
+	var messages []MessageRequest
+	json.Unmarshal(requests[1]["messages"], &messages)
+	assistant := messages[2]
+	if assistant.Role != RoleAssistant || len(assistant.ReasoningDetails) != 1 || assistant.ReasoningDetails[0].Data != "secret" || assistant.ReasoningDetails[0].ID != "r1" {
+		t.Fatalf("reasoning details not sent back: %+v", assistant)
+	}
+}
+
+func TestRouterAgentChat_ToolArgumentCorrections(t *testing.T) {
+	var toolMessages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := Request{}
+		json.NewDecoder(r.Body).Decode(&request)
+		if last := request.Messages[len(request.Messages)-1]; last.Role == RoleTool {
+			toolMessages = append(toolMessages, last.Content[0].Text)
+		}
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"","tool_calls":[{"id":"1","type":"function","function":{"name":"search","arguments":"{\"sort\":\"name\"}"}}]}}]}`)
+	}))
+	defer server.Close()
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client())
+	agent := NewRouterAgentChat(client, "m", RouterAgentConfig{}, "system")
+	agent.MaxToolCorrections = 2
+	type args struct {
+		Query string `json:"query"`
+		Sort  string `json:"sort,omitempty" enum:"relevance,date"`
+	}
+	if err := AddToolToAgent(&agent, ToolDefinition[args]{Name: "search", Function: func(args) any { return nil }}); err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+
+	_, err := agent.Chat("find go")
+	if !errors.Is(err, ErrTooManyToolCorrections) {
+		t.Fatalf("expected too many corrections, got %v", err)
+	}
+	var argsErr *ToolArgumentsError
+	if !errors.As(err, &argsErr) {
+		t.Fatalf("expected the last argument error to be wrapped, got %v", err)
+	}
+	if len(toolMessages) != 2 {
+		t.Fatalf("expected 2 correction rounds, got %d", len(toolMessages))
+	}
+
+	output := toolErrorOutput{}
+	if err := json.Unmarshal([]byte(toolMessages[0]), &output); err != nil {
+		t.Fatalf("tool message is not JSON: %s", toolMessages[0])
+	}
+	paths := map[string]*ValidationError{}
+	for _, validationErr := range output.ValidationErrors {
+		paths[validationErr.Path] = validationErr
+	}
+	if paths["$.query"] == nil || paths["$.sort"] == nil || len(paths["$.sort"].Allowed) != 2 {
+		t.Fatalf("unexpected validation errors: %s", toolMessages[0])
+	}
+	if len(agent.Messages) != 1 {
+		t.Fatalf("history must stay untouched on failure, got %d messages", len(agent.Messages))
+	}
+}
+
+type weatherReport struct {
+	TempC float64 `json:"temp_c"`
+}
+
+type ctxKey struct{}
+
+func TestRouterAgentChat_ContextTool(t *testing.T) {
+	var requests []Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := Request{}
+		json.NewDecoder(r.Body).Decode(&request)
+		requests = append(requests, request)
+		if len(requests) == 1 {
+			fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"","tool_calls":[{"id":"1","type":"function","function":{"name":"weather","arguments":"{\"city\":\"Oslo\"}"}},{"id":"2","type":"function","function":{"name":"weather","arguments":"{\"city\":\"Atlantis\"}"}}]}}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"done"}}]}`)
+	}))
+	defer server.Close()
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client())
+	agent := NewRouterAgentChat(client, "m", RouterAgentConfig{}, "system")
+	type args struct {
+		City string `json:"city"`
+	}
+	var seen []interface{}
+	err := AddContextToolToAgent(&agent, ContextToolDefinition[args, weatherReport]{
+		Name:        "weather",
+		Description: "Current weather",
+		Function: func(ctx context.Context, a args) (weatherReport, error) {
+			seen = append(seen, ctx.Value(ctxKey{}))
+			if a.City == "Atlantis" {
+				return weatherReport{}, errors.New("unknown city")
+			}
+			return weatherReport{TempC: 12.5}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "chat")
+	if _, err := agent.ChatContext(ctx, "weather?"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(seen) != 2 || seen[0] != "chat" || seen[1] != "chat" {
+		t.Fatalf("tool did not receive the chat context: %v", seen)
+	}
+	description := requests[0].Tools[0].Function.Description
+	if !strings.HasPrefix(description, "Current weather") || !strings.Contains(description, `"temp_c":{"type":"number"}`) {
+		t.Fatalf("expected result schema in description, got %q", description)
+	}
+	messages := requests[1].Messages
+	if got := messages[len(messages)-2].Content[0].Text; got != `{"temp_c":12.5}` {
+		t.Fatalf("unexpected tool result: %s", got)
+	}
+	if got := messages[len(messages)-1].Content[0].Text; got != `{"error":"unknown city"}` {
+		t.Fatalf("unexpected tool error payload: %s", got)
+	}
+}
@@ -1,200 +1,336 @@
 package openrouterapigo
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
-	"strings"
+	"time"
 )
 
 type OpenRouterClient struct {
-	apiKey     string
-	apiURL     string
-	httpClient *http.Client
+	apiKey       string
+	apiURL       string
+	httpClient   *http.Client
+	retryPolicy  RetryPolicy
+	headers      http.Header
+	defaultModel string
+	timeout      time.Duration
+
+	streamIdleTimeout time.Duration
+	firstTokenTimeout time.Duration
+
+	logger       *slog.Logger
+	middleware   []Middleware
+	interceptors []Interceptor
+
+	generationHook  GenerationHook
+	streamStatsHook StreamStatsHook
+
+	modelsCacheTTL time.Duration
+	modelsCache    modelsCache
 }
 
-func NewOpenRouterClient(apiKey string) *OpenRouterClient {
-	return &OpenRouterClient{
-		apiKey:     apiKey,
-		apiURL:     "https://openrouter.ai/api/v1",
-		httpClient: &http.Client{},
-	}
+func NewOpenRouterClient(apiKey string, opts ...ClientOption) *OpenRouterClient {
+	return NewOpenRouterClientFull(apiKey, "https://openrouter.ai/api/v1", &http.Client{}, opts...)
 }
 
-func NewOpenRouterClientFull(apiKey string, apiUrl string, client *http.Client) *OpenRouterClient {
-	return &OpenRouterClient{
-		apiKey:     apiKey,
-		apiURL:     apiUrl,
-		httpClient: client,
+func NewOpenRouterClientFull(apiKey string, apiUrl string, client *http.Client, opts ...ClientOption) *OpenRouterClient {
+	c := &OpenRouterClient{
+		apiKey:         apiKey,
+		apiURL:         apiUrl,
+		httpClient:     client,
+		headers:        http.Header{},
+		modelsCacheTTL: DefaultModelsCacheTTL,
 	}
+	c.applyOptions(opts)
+	return c
 }
 
-func (c *OpenRouterClient) FetchChatCompletions(request Request) (*Response, error) {
-	headers := map[string]string{
-		"Authorization": "Bearer " + c.apiKey,
-		"Content-Type":  "application/json",
-	}
+// SetRetryPolicy sets the policy used by every call of the client, including
+// the RouterAgent and RouterAgentChat calls built on top of it.
+func (c *OpenRouterClient) SetRetryPolicy(policy RetryPolicy) {
+	c.retryPolicy = policy
+}
 
-	if request.Provider != nil {
-		headers["HTTP-Referer"] = request.Provider.RefererURL
-		headers["X-Title"] = request.Provider.SiteName
+// newRequest builds an authenticated request to the given API path. A nil
+// body sends no payload.
+func (c *OpenRouterClient) newRequest(ctx context.Context, method string, path string, body any) (*http.Request, error) {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		payload = bytes.NewBuffer(data)
 	}
 
-	body, err := json.Marshal(request)
+	req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("%s%s", c.apiURL, path), payload)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/chat/completions", c.apiURL), bytes.NewBuffer(body))
+	for key, values := range c.headers {
+		req.Header[key] = append([]string(nil), values...)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+func (c *OpenRouterClient) newChatCompletionsRequest(ctx context.Context, request Request) (*http.Request, error) {
+	provider := request.Provider
+	request.Provider = provider.routing()
+	req, err := c.newRequest(ctx, "POST", "/chat/completions", request)
 	if err != nil {
 		return nil, err
 	}
 
-	for key, value := range headers {
-		req.Header.Set(key, value)
+	setRequestHeaders(req, provider, request.Headers)
+	return req, nil
+}
+
+// routing returns the preferences to send in the body, or nil when only the
+// deprecated header fields are set, so no empty provider object is sent.
+func (p *ProviderPreferences) routing() *ProviderPreferences {
+	if p == nil {
+		return nil
 	}
+	if len(p.Order) == 0 && len(p.Only) == 0 && len(p.Ignore) == 0 && p.AllowFallbacks == nil &&
+		!p.RequireParameters && p.DataCollection == "" && p.ZDR == nil && len(p.Quantizations) == 0 &&
+		p.Sort == "" && p.MaxPrice == nil {
+		return nil
+	}
+	return p
+}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
+// setRequestHeaders sets the per-request headers, overriding the client
+// defaults. The deprecated attribution fields of provider preferences are
+// still honored.
+func setRequestHeaders(req *http.Request, provider *ProviderPreferences, headers http.Header) {
+	if provider != nil {
+		if provider.RefererURL != "" {
+			req.Header.Set("HTTP-Referer", provider.RefererURL)
+		}
+		if provider.SiteName != "" {
+			req.Header.Set("X-Title", provider.SiteName)
+		}
+	}
+	for key, values := range headers {
+		req.Header[http.CanonicalHeaderKey(key)] = values
+	}
+}
+
+// attemptContext derives the context of a single HTTP attempt. The returned
+// stop function ends the timeout window without cancelling the context, so
+// streams can keep reading the body; cancel releases the context.
+func (c *OpenRouterClient) attemptContext(ctx context.Context) (attemptCtx context.Context, stop func() bool, cancel context.CancelFunc) {
+	attemptCtx, cancelCause := context.WithCancelCause(ctx)
+	cancel = func() { cancelCause(context.Canceled) }
+	if c.timeout <= 0 {
+		return attemptCtx, func() bool { return true }, cancel
+	}
+
+	timer := time.AfterFunc(c.timeout, func() {
+		cancelCause(fmt.Errorf("openrouter: no response within %s: %w", c.timeout, context.DeadlineExceeded))
+	})
+	return attemptCtx, timer.Stop, cancel
+}
+
+// timeoutError replaces the generic cancellation error of an attempt that hit
+// the client timeout with the timeout cause.
+func timeoutError(attemptCtx context.Context, parent context.Context, err error) error {
+	if err == nil || parent.Err() != nil {
+		return err
+	}
+	if cause := context.Cause(attemptCtx); errors.Is(cause, context.DeadlineExceeded) {
+		return cause
 	}
-	defer resp.Body.Close()
+	return err
+}
+
+func (c *OpenRouterClient) logDebug(ctx context.Context, msg string, args ...any) {
+	if c.logger != nil {
+		c.logger.DebugContext(ctx, msg, args...)
+	}
+}
+
+func (c *OpenRouterClient) logWarn(ctx context.Context, msg string, args ...any) {
+	if c.logger != nil {
+		c.logger.WarnContext(ctx, msg, args...)
+	}
+}
 
-	output, err := io.ReadAll(resp.Body)
+// send performs a single attempt. Non-200 responses are consumed and returned
+// as *APIError; on success the caller owns the response body.
+func (c *OpenRouterClient) send(req *http.Request) (*http.Response, error) {
+	c.logDebug(req.Context(), "openrouter request", "method", req.Method, "path", req.URL.Path)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("%d: %s", resp.StatusCode, output)
+		defer resp.Body.Close()
+		output, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return nil, newAPIError(resp, output)
 	}
+	return resp, nil
+}
 
-	outputReponse := &Response{}
-	err = json.Unmarshal(output, outputReponse)
+// doJSON sends the request built by newReq with the client timeout and retry
+// policy, and decodes the 200 response into out. newReq is called for every
+// attempt. Failures reading a 200 body are not retried: the request was
+// already processed, and billed, by then.
+func (c *OpenRouterClient) doJSON(ctx context.Context, newReq func(ctx context.Context) (*http.Request, error), out any) error {
+	var output []byte
+	err := c.withRetry(ctx, func() error {
+		attemptCtx, _, cancel := c.attemptContext(ctx)
+		defer cancel()
+
+		req, err := newReq(attemptCtx)
+		if err != nil {
+			return permanentError{err}
+		}
+
+		resp, err := c.send(req)
+		if err != nil {
+			return timeoutError(attemptCtx, ctx, err)
+		}
+		defer resp.Body.Close()
+
+		output, err = io.ReadAll(resp.Body)
+		if err != nil {
+			return permanentError{timeoutError(attemptCtx, ctx, err)}
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return outputReponse, nil
+	return json.Unmarshal(output, out)
 }
 
-func (c *OpenRouterClient) FetchChatCompletionsStream(request Request, outputChan chan Response, processingChan chan interface{}, errChan chan error, ctx context.Context) {
-	headers := map[string]string{
-		"Authorization": "Bearer " + c.apiKey,
-		"Content-Type":  "application/json",
-	}
+// getJSON sends a GET request to path and decodes the response into out.
+func (c *OpenRouterClient) getJSON(ctx context.Context, path string, out any) error {
+	return c.doJSON(ctx, func(ctx context.Context) (*http.Request, error) {
+		return c.newRequest(ctx, "GET", path, nil)
+	}, out)
+}
 
-	if request.Provider != nil {
-		headers["HTTP-Referer"] = request.Provider.RefererURL
-		headers["X-Title"] = request.Provider.SiteName
-	}
+func (c *OpenRouterClient) FetchChatCompletions(request Request) (*Response, error) {
+	return c.FetchChatCompletionsContext(context.Background(), request)
+}
 
-	body, err := json.Marshal(request)
+// FetchChatCompletionsContext is like FetchChatCompletions but aborts the
+// HTTP call when ctx is cancelled or its deadline passes.
+func (c *OpenRouterClient) FetchChatCompletionsContext(ctx context.Context, request Request) (*Response, error) {
+	cached, intercepted, err := c.interceptRequest(ctx, &request)
 	if err != nil {
-		errChan <- err
-		close(errChan)
-		close(outputChan)
-		close(processingChan)
-		return
+		return nil, err
+	}
+	if cached != nil {
+		if err := c.interceptResponse(ctx, &request, cached, intercepted); err != nil {
+			return nil, err
+		}
+		return cached, nil
 	}
 
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/chat/completions", c.apiURL), bytes.NewBuffer(body))
+	outputReponse := &Response{}
+	err = c.doJSON(ctx, func(ctx context.Context) (*http.Request, error) {
+		return c.newChatCompletionsRequest(ctx, request)
+	}, outputReponse)
 	if err != nil {
-		errChan <- err
-		close(errChan)
-		close(outputChan)
-		close(processingChan)
-		return
+		return nil, err
 	}
 
-	req = req.WithContext(ctx)
+	c.resolveGeneration(ctx, outputReponse.ID)
 
-	for key, value := range headers {
-		req.Header.Set(key, value)
+	if err := c.interceptResponse(ctx, &request, outputReponse, intercepted); err != nil {
+		return nil, err
 	}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		errChan <- err
-		close(errChan)
-		close(outputChan)
-		close(processingChan)
-		return
-	}
+	return outputReponse, nil
+}
 
-	go func() {
-		defer resp.Body.Close()
+// FetchChatCompletionsStream sends a streaming request and delivers chunks on
+// outputChan until the stream ends, then closes all three channels. Stream is
+// set on the request. Failures before the first chunk are retried according
+// to the client retry policy.
+func (c *OpenRouterClient) FetchChatCompletionsStream(request Request, outputChan chan Response, processingChan chan interface{}, errChan chan error, ctx context.Context) {
+	c.fetchChatCompletionsStream(request, outputChan, processingChan, errChan, ctx, newStreamRecorder())
+}
 
+func (c *OpenRouterClient) fetchChatCompletionsStream(request Request, outputChan chan Response, processingChan chan interface{}, errChan chan error, ctx context.Context, recorder *streamRecorder) {
+	request.Stream = true
+
+	go func() {
 		defer close(errChan)
 		defer close(outputChan)
 		defer close(processingChan)
-		if resp.StatusCode != http.StatusOK {
-			errChan <- fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-			return
-		}
 
-		reader := bufio.NewReader(resp.Body)
-		for {
-			select {
-			case <-ctx.Done():
-				errChan <- ctx.Err()
-				return
-			default:
-				line, err := reader.ReadString('\n')
-				line = strings.TrimSpace(line)
-				if strings.HasPrefix(line, ":") {
-					select {
-					case processingChan <- true:
-					case <-ctx.Done():
-						errChan <- ctx.Err()
-						return
-					}
-					continue
-				}
-
-				if line != "" {
-					if !strings.HasPrefix(line, "data:") || len(line) < len("data:") {
-						errChan <- fmt.Errorf("unexpected response line: %q", line)
-						return
-					}
-					payload := strings.TrimSpace(line[len("data:"):])
-					if payload == "[DONE]" {
-						return
-					}
-					response := Response{}
-					err = json.Unmarshal([]byte(payload), &response)
-					if err != nil {
-						errChan <- err
-						return
-					}
-					select {
-					case outputChan <- response:
-					case <-ctx.Done():
-						errChan <- ctx.Err()
-						return
-					}
-				}
-
-				if err != nil {
-					if err == io.EOF {
-						return
-					}
-					errChan <- err
-					return
-				}
-			}
+		err := c.streamChatCompletions(ctx, request, outputChan, processingChan, recorder)
+		c.finishStream(recorder, err)
+		if err != nil {
+			errChan <- err
 		}
 	}()
 }
 
+// streamChatCompletions runs the interceptors around a chat stream and
+// forwards its chunks to outputChan.
+func (c *OpenRouterClient) streamChatCompletions(ctx context.Context, request Request, outputChan chan Response, processingChan chan interface{}, recorder *streamRecorder) error {
+	cached, intercepted, err := c.interceptRequest(ctx, &request)
+	if err != nil {
+		return err
+	}
+	generationID := ""
+	onChunk := func(response *Response) error {
+		if generationID == "" {
+			generationID = response.ID
+		}
+		return c.interceptResponse(ctx, &request, response, intercepted)
+	}
+	if cached != nil {
+		recorder.chunk(cached)
+		if err := onChunk(cached); err != nil {
+			return err
+		}
+		select {
+		case outputChan <- *cached:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	err = streamWithRetry(c, ctx, func(ctx context.Context) (*http.Request, error) {
+		return c.newChatCompletionsRequest(ctx, request)
+	}, outputChan, processingChan, recorder, onChunk)
+	if err != nil {
+		return err
+	}
+	c.resolveGeneration(ctx, generationID)
+	return nil
+}
+
 type StreamEvent struct {
 	Response   *Response
 	Processing bool
-	Err        error
+	// Usage is set on the event of the chunk carrying the usage, the final
+	// chunk of streams with usage accounting enabled.
+	Usage *ResponseUsage
+	Err   error
 }
 
 // ChatCompletionsStream wraps streaming channels into a pull-based iterator.
@@ -202,11 +338,14 @@ type ChatCompletionsStream struct {
 	output     <-chan Response
 	processing <-chan interface{}
 	errs       <-chan error
+	cancel     context.CancelFunc
+	recorder   *streamRecorder
 	done       bool
 }
 
 // Recv returns the next stream event. ok=false means the stream ended.
-// If an error is returned in the event, the stream is finished.
+// If an error is returned in the event, the stream is finished. When ctx is
+// done the stream is closed.
 func (s *ChatCompletionsStream) Recv(ctx context.Context) (StreamEvent, bool) {
 	if s.done {
 		return StreamEvent{}, false
@@ -224,7 +363,7 @@ func (s *ChatCompletionsStream) Recv(ctx context.Context) (StreamEvent, bool) {
 				s.output = nil
 				continue
 			}
-			return StreamEvent{Response: &resp}, true
+			return StreamEvent{Response: &resp, Usage: resp.Usage}, true
 		case _, ok := <-s.processing:
 			if !ok {
 				s.processing = nil
@@ -243,21 +382,65 @@ func (s *ChatCompletionsStream) Recv(ctx context.Context) (StreamEvent, bool) {
 			}
 			return StreamEvent{}, false
 		case <-ctx.Done():
-			s.done = true
+			s.Close()
 			return StreamEvent{Err: ctx.Err()}, false
 		}
 	}
 }
 
-// StartChatCompletionsStream starts a streaming request and returns an iterator-style wrapper.
+// Close cancels the request, releases the response body and waits for the
+// goroutine feeding the stream to exit. It is safe to call more than once and
+// after the stream ended.
+func (s *ChatCompletionsStream) Close() error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.drain()
+	return nil
+}
+
+// Stats returns the timing of the stream so far. Once Recv reported the end of
+// the stream or an error, the stats are final.
+func (s *ChatCompletionsStream) Stats() StreamStats {
+	return s.recorder.snapshot()
+}
+
+// drain discards the remaining events so the goroutine feeding the stream can
+// exit. The stream context must already be done.
+func (s *ChatCompletionsStream) drain() {
+	for s.output != nil || s.processing != nil || s.errs != nil {
+		select {
+		case _, ok := <-s.output:
+			if !ok {
+				s.output = nil
+			}
+		case _, ok := <-s.processing:
+			if !ok {
+				s.processing = nil
+			}
+		case _, ok := <-s.errs:
+			if !ok {
+				s.errs = nil
+			}
+		}
+	}
+	s.done = true
+}
+
+// StartChatCompletionsStream starts a streaming request and returns an
+// iterator-style wrapper. Call Close when not reading the stream to its end.
 func (c *OpenRouterClient) StartChatCompletionsStream(request Request, ctx context.Context) *ChatCompletionsStream {
+	ctx, cancel := context.WithCancel(ctx)
 	outputChan := make(chan Response)
 	processingChan := make(chan interface{})
 	errChan := make(chan error)
-	c.FetchChatCompletionsStream(request, outputChan, processingChan, errChan, ctx)
+	recorder := newStreamRecorder()
+	c.fetchChatCompletionsStream(request, outputChan, processingChan, errChan, ctx, recorder)
 	return &ChatCompletionsStream{
 		output:     outputChan,
 		processing: processingChan,
 		errs:       errChan,
+		cancel:     cancel,
+		recorder:   recorder,
 	}
 }
@@ -2,6 +2,12 @@ package openrouterapigo
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -49,3 +55,220 @@ func TestChatCompletionsStreamRecv(t *testing.T) {
 		t.Fatalf("expected stream to end")
 	}
 }
+
+func TestFetchChatCompletionsContext_Cancel(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client())
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.FetchChatCompletionsContext(ctx, Request{Model: "m"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestChatCompletionsStreamClose(t *testing.T) {
+	released := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 100; i++ {
+			fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"x\"}}]}\n\n")
+			w.(http.Flusher).Flush()
+		}
+		<-r.Context().Done()
+		close(released)
+	}))
+	defer server.Close()
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client())
+	stream := client.StartChatCompletionsStream(Request{Model: "m"}, context.Background())
+	if ev, ok := stream.Recv(context.Background()); !ok || ev.Response == nil {
+		t.Fatalf("expected a chunk, got %+v", ev)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		stream.Close()
+		stream.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Close did not return")
+	}
+	select {
+	case <-released:
+	case <-time.After(time.Second):
+		t.Fatal("request was not cancelled by Close")
+	}
+	if _, ok := stream.Recv(context.Background()); ok {
+		t.Fatal("closed stream must not yield events")
+	}
+}
+
+func TestFetchChatCompletionsStream_Timeouts(t *testing.T) {
+	tests := []struct {
+		name    string
+		option  ClientOption
+		handler func(w http.ResponseWriter, r *http.Request)
+		// recvDelay simulates a slow consumer between Recv calls.
+		recvDelay time.Duration
+		want      error
+	}{
+		{
+			name:   "idle",
+			option: WithStreamIdleTimeout(50 * time.Millisecond),
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"x\"}}]}\n\n")
+				w.(http.Flusher).Flush()
+				<-r.Context().Done()
+			},
+			want: ErrStreamIdleTimeout,
+		},
+		{
+			name:   "keep-alives reset idle timeout",
+			option: WithStreamIdleTimeout(50 * time.Millisecond),
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				for i := 0; i < 5; i++ {
+					fmt.Fprint(w, ": OPENROUTER PROCESSING\n\n")
+					w.(http.Flusher).Flush()
+					time.Sleep(20 * time.Millisecond)
+				}
+				fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"x\"}}]}\n\ndata: [DONE]\n\n")
+			},
+		},
+		{
+			name:   "slow consumer does not trigger idle timeout",
+			option: WithStreamIdleTimeout(100 * time.Millisecond),
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				for i := 0; i < 5; i++ {
+					fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"x\"}}]}\n\n")
+					w.(http.Flusher).Flush()
+					time.Sleep(20 * time.Millisecond)
+				}
+				fmt.Fprint(w, "data: [DONE]\n\n")
+			},
+			recvDelay: 200 * time.Millisecond,
+		},
+		{
+			name:   "first token",
+			option: WithFirstTokenTimeout(50 * time.Millisecond),
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				for {
+					fmt.Fprint(w, ": OPENROUTER PROCESSING\n\n")
+					w.(http.Flusher).Flush()
+					select {
+					case <-r.Context().Done():
+						return
+					case <-time.After(10 * time.Millisecond):
+					}
+				}
+			},
+			want: ErrFirstTokenTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(tt.handler))
+			defer server.Close()
+
+			client := NewOpenRouterClientFull("key", server.URL, server.Client(), tt.option)
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+
+			stream := client.StartChatCompletionsStream(Request{Model: "m"}, ctx)
+			defer stream.Close()
+			var streamErr error
+			for {
+				ev, ok := stream.Recv(ctx)
+				if ev.Err != nil {
+					streamErr = ev.Err
+					break
+				}
+				if !ok {
+					break
+				}
+				time.Sleep(tt.recvDelay)
+			}
+			if tt.want == nil && streamErr != nil {
+				t.Fatalf("unexpected error: %v", streamErr)
+			}
+			if tt.want != nil && !errors.Is(streamErr, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, streamErr)
+			}
+		})
+	}
+}
+
+func TestUsageAccounting(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		usage := `{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15,"cost":0.0012,"prompt_tokens_details":{"cached_tokens":8},"completion_tokens_details":{"reasoning_tokens":3},"cost_details":{"upstream_inference_cost":0.001}}`
+		if strings.Contains(string(body), `"stream":true`) {
+			fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\n")
+			fmt.Fprintf(w, "data: {\"choices\":[],\"usage\":%s}\n\ndata: [DONE]\n\n", usage)
+			return
+		}
+		fmt.Fprintf(w, `{"choices":[{"message":{"role":"assistant","content":"hi"}}],"usage":%s}`, usage)
+	}))
+	defer server.Close()
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client())
+	agent := NewRouterAgent(client, "m", RouterAgentConfig{Usage: &UsageConfig{Include: true}})
+
+	checkUsage := func(usage *ResponseUsage) {
+		t.Helper()
+		if usage == nil || usage.Cost != 0.0012 || usage.PromptTokensDetails == nil || usage.PromptTokensDetails.CachedTokens != 8 ||
+			usage.CompletionTokensDetails == nil || usage.CompletionTokensDetails.ReasoningTokens != 3 ||
+			usage.CostDetails == nil || usage.CostDetails.UpstreamInferenceCost != 0.001 {
+			t.Fatalf("unexpected usage: %+v", usage)
+		}
+	}
+
+	resp, err := agent.Chat([]MessageRequest{{Role: RoleUser, Content: TextContent("hi")}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkUsage(resp.Usage)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	stream := agent.StartChatStream([]MessageRequest{{Role: RoleUser, Content: TextContent("hi")}}, ctx)
+	defer stream.Close()
+	var usageEvents []*ResponseUsage
+	for {
+		ev, ok := stream.Recv(ctx)
+		if ev.Err != nil {
+			t.Fatalf("unexpected error: %v", ev.Err)
+		}
+		if !ok {
+			break
+		}
+		if ev.Usage != nil {
+			usageEvents = append(usageEvents, ev.Usage)
+		}
+	}
+	if len(usageEvents) != 1 {
+		t.Fatalf("expected one usage event, got %d", len(usageEvents))
+	}
+	checkUsage(usageEvents[0])
+
+	for _, body := range bodies {
+		if !strings.Contains(body, `"usage":{"include":true}`) {
+			t.Fatalf("usage accounting not requested: %s", body)
+		}
+	}
+}
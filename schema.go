@@ -0,0 +1,424 @@
+package openrouterapigo
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// generateSchema returns the JSON schema of a struct value, for tool
+// arguments and structured outputs. Inputs other than structs and pointers to
+// structs produce an empty schema.
+//
+// Fields are named by their json tag and are required unless tagged
+// omitempty or of pointer type; fields without json tag are skipped, except
+// embedded structs, whose fields are flattened into the parent; on name
+// conflicts the shallowest field wins, as in encoding/json. Objects do not
+// allow properties other than their fields. Maps become
+// objects with additionalProperties, time.Time a date-time string, []byte a
+// base64 string and interfaces an unconstrained schema. Recursive types are
+// emitted once under $defs and referenced with $ref.
+//
+// These field tags refine the schema:
+//
+//	jsonschema, desc  description
+//	enum              comma separated allowed values
+//	minimum, maximum  numeric bounds
+//	minLength, maxLength, pattern  string constraints
+//	default           default value
+//	examples          comma separated values, or a JSON array
+//
+// On slices, enum, bounds and string constraints apply to the items.
+func generateSchema(input any) map[string]interface{} {
+	t := reflect.TypeOf(input)
+	if t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return map[string]interface{}{}
+	}
+
+	g := &schemaGenerator{
+		root:       t,
+		building:   map[reflect.Type]bool{},
+		referenced: map[reflect.Type]bool{},
+		defNames:   map[reflect.Type]string{},
+		defs:       map[string]interface{}{},
+	}
+	schema := g.structSchema(t)
+	if len(g.defs) > 0 {
+		schema["$defs"] = g.defs
+	}
+	return schema
+}
+
+// generateTypeSchema is like generateSchema but accepts any type, e.g. to
+// document tool results.
+func generateTypeSchema(t reflect.Type) map[string]interface{} {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct {
+		return generateSchema(reflect.New(t).Elem().Interface())
+	}
+
+	g := &schemaGenerator{
+		building:   map[reflect.Type]bool{},
+		referenced: map[reflect.Type]bool{},
+		defNames:   map[reflect.Type]string{},
+		defs:       map[string]interface{}{},
+	}
+	schema := g.typeSchema(t)
+	if len(g.defs) > 0 {
+		schema["$defs"] = g.defs
+	}
+	return schema
+}
+
+type schemaGenerator struct {
+	root reflect.Type
+	// building holds the struct types being generated, to detect recursion.
+	building map[reflect.Type]bool
+	// referenced marks recursive types that are emitted under $defs.
+	referenced map[reflect.Type]bool
+	defNames   map[reflect.Type]string
+	defs       map[string]interface{}
+}
+
+// typeSchema returns the schema of a Go type.
+func (g *schemaGenerator) typeSchema(t reflect.Type) map[string]interface{} {
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case rawMessageType:
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.typeSchema(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{"type": "array", "items": g.typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.typeSchema(t.Elem())}
+	case reflect.Struct:
+		return g.structSchema(t)
+	case reflect.Interface:
+		return map[string]interface{}{}
+	default:
+		return map[string]interface{}{"type": "object"}
+	}
+}
+
+// structSchema returns the object schema of a struct, or a $ref when the
+// struct is recursive.
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	if g.building[t] {
+		g.referenced[t] = true
+		return g.ref(t)
+	}
+	g.building[t] = true
+	defer delete(g.building, t)
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           map[string]interface{}{},
+		"required":             []string{},
+		"additionalProperties": false,
+	}
+	g.addFields(schema, t)
+
+	if g.referenced[t] && t != g.root {
+		g.defs[g.defName(t)] = schema
+		return g.ref(t)
+	}
+	return schema
+}
+
+// addFields adds the properties of the struct fields to schema, flattening
+// embedded structs.
+func (g *schemaGenerator) addFields(schema map[string]interface{}, t reflect.Type) {
+	properties := schema["properties"].(map[string]interface{})
+	for _, field := range jsonFields(t) {
+		propSchema := g.typeSchema(field.Type)
+		applySchemaTags(propSchema, field.StructField)
+		properties[field.name] = propSchema
+
+		if !field.omitempty && field.Type.Kind() != reflect.Pointer {
+			schema["required"] = append(schema["required"].([]string), field.name)
+		}
+	}
+}
+
+type jsonField struct {
+	reflect.StructField
+	name      string
+	omitempty bool
+	depth     int
+}
+
+// jsonFields lists the tagged fields of t in field order, with embedded
+// structs flattened. A name used by several fields resolves like
+// encoding/json: the shallowest field wins, and fields tied at the same depth
+// are all dropped.
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+	collectJSONFields(t, 0, map[reflect.Type]bool{}, &fields)
+
+	shallowest := map[string]int{}
+	count := map[string]int{}
+	for _, field := range fields {
+		depth, seen := shallowest[field.name]
+		switch {
+		case !seen || field.depth < depth:
+			shallowest[field.name] = field.depth
+			count[field.name] = 1
+		case field.depth == depth:
+			count[field.name]++
+		}
+	}
+
+	resolved := fields[:0]
+	for _, field := range fields {
+		if field.depth == shallowest[field.name] && count[field.name] == 1 {
+			resolved = append(resolved, field)
+		}
+	}
+	return resolved
+}
+
+func collectJSONFields(t reflect.Type, depth int, visiting map[reflect.Type]bool, fields *[]jsonField) {
+	if visiting[t] {
+		return
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		jsonTag := field.Tag.Get("json")
+		fieldName := strings.Split(jsonTag, ",")[0]
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && fieldName == "" && jsonTag != "-" && fieldType.Kind() == reflect.Struct {
+			collectJSONFields(fieldType, depth+1, visiting, fields)
+			continue
+		}
+
+		if jsonTag == "" || jsonTag == "-" || !field.IsExported() {
+			continue
+		}
+		if fieldName == "" {
+			fieldName = field.Name
+		}
+		*fields = append(*fields, jsonField{
+			StructField: field,
+			name:        fieldName,
+			omitempty:   strings.Contains(jsonTag, "omitempty"),
+			depth:       depth,
+		})
+	}
+}
+
+func (g *schemaGenerator) ref(t reflect.Type) map[string]interface{} {
+	if t == g.root {
+		return map[string]interface{}{"$ref": "#"}
+	}
+	return map[string]interface{}{"$ref": "#/$defs/" + g.defName(t)}
+}
+
+// defName returns a $defs key for t, unique among the generated types.
+func (g *schemaGenerator) defName(t reflect.Type) string {
+	if name, ok := g.defNames[t]; ok {
+		return name
+	}
+	name := t.Name()
+	for i := 2; g.defNameTaken(name); i++ {
+		name = fmt.Sprintf("%s%d", t.Name(), i)
+	}
+	g.defNames[t] = name
+	return name
+}
+
+func (g *schemaGenerator) defNameTaken(name string) bool {
+	for _, other := range g.defNames {
+		if other == name {
+			return true
+		}
+	}
+	return false
+}
+
+// strictSchema returns a copy of a generated schema in the form strict
+// structured outputs require: every property is required, and optional ones
+// accept null instead. It reports false, returning schema unchanged, when the
+// schema has parts strict mode cannot express, such as maps or interfaces.
+func strictSchema(schema map[string]interface{}) (map[string]interface{}, bool) {
+	if len(schema) == 0 {
+		return schema, false
+	}
+	if _, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+		return schema, false
+	}
+
+	strict := make(map[string]interface{}, len(schema))
+	for key, value := range schema {
+		strict[key] = value
+	}
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		converted, ok := strictSchema(items)
+		if !ok {
+			return schema, false
+		}
+		strict["items"] = converted
+	}
+	if defs, ok := schema["$defs"].(map[string]interface{}); ok {
+		converted := make(map[string]interface{}, len(defs))
+		for name, def := range defs {
+			convertedDef, ok := strictSchema(def.(map[string]interface{}))
+			if !ok {
+				return schema, false
+			}
+			converted[name] = convertedDef
+		}
+		strict["$defs"] = converted
+	}
+	if properties, ok := schema["properties"].(map[string]interface{}); ok {
+		required := append([]string(nil), schemaStrings(schema["required"])...)
+		isRequired := map[string]bool{}
+		for _, name := range required {
+			isRequired[name] = true
+		}
+		names := make([]string, 0, len(properties))
+		for name := range properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		converted := make(map[string]interface{}, len(properties))
+		for _, name := range names {
+			property, ok := strictSchema(properties[name].(map[string]interface{}))
+			if !ok {
+				return schema, false
+			}
+			if !isRequired[name] {
+				property = nullableSchema(property)
+				required = append(required, name)
+			}
+			converted[name] = property
+		}
+		strict["properties"] = converted
+		strict["required"] = required
+	}
+	return strict, true
+}
+
+// nullableSchema extends schema to also accept null.
+func nullableSchema(schema map[string]interface{}) map[string]interface{} {
+	jsonType, ok := schema["type"].(string)
+	if !ok {
+		return map[string]interface{}{"anyOf": []interface{}{schema, map[string]interface{}{"type": "null"}}}
+	}
+	nullable := make(map[string]interface{}, len(schema))
+	for key, value := range schema {
+		nullable[key] = value
+	}
+	nullable["type"] = []string{jsonType, "null"}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		nullable["enum"] = append(append([]interface{}(nil), enum...), nil)
+	}
+	return nullable
+}
+
+// applySchemaTags adds the description and constraint tags of field to its
+// schema.
+func applySchemaTags(schema map[string]interface{}, field reflect.StructField) {
+	if desc := field.Tag.Get("jsonschema"); desc != "" {
+		schema["description"] = desc
+	} else if desc := field.Tag.Get("desc"); desc != "" {
+		schema["description"] = desc
+	}
+
+	valueSchema := schema
+	if items, ok := schema["items"].(map[string]interface{}); ok && schema["type"] == "array" {
+		valueSchema = items
+	}
+	valueType, _ := valueSchema["type"].(string)
+
+	if enum, ok := field.Tag.Lookup("enum"); ok {
+		values := []interface{}{}
+		for _, value := range strings.Split(enum, ",") {
+			values = append(values, parseSchemaTagValue(valueType, strings.TrimSpace(value)))
+		}
+		valueSchema["enum"] = values
+	}
+	for _, key := range []string{"minimum", "maximum"} {
+		if value, ok := field.Tag.Lookup(key); ok {
+			if number, err := strconv.ParseFloat(value, 64); err == nil {
+				valueSchema[key] = number
+			}
+		}
+	}
+	for _, key := range []string{"minLength", "maxLength"} {
+		if value, ok := field.Tag.Lookup(key); ok {
+			if length, err := strconv.Atoi(value); err == nil {
+				valueSchema[key] = length
+			}
+		}
+	}
+	if pattern, ok := field.Tag.Lookup("pattern"); ok {
+		valueSchema["pattern"] = pattern
+	}
+
+	fieldType, _ := schema["type"].(string)
+	if value, ok := field.Tag.Lookup("default"); ok {
+		schema["default"] = parseSchemaTagValue(fieldType, value)
+	}
+	if value, ok := field.Tag.Lookup("examples"); ok {
+		var examples []interface{}
+		if err := json.Unmarshal([]byte(value), &examples); err != nil {
+			examples = []interface{}{}
+			for _, example := range strings.Split(value, ",") {
+				examples = append(examples, parseSchemaTagValue(fieldType, strings.TrimSpace(example)))
+			}
+		}
+		schema["examples"] = examples
+	}
+}
+
+// parseSchemaTagValue converts a tag value to the JSON type of the schema it
+// belongs to. Values that do not parse are kept as strings.
+func parseSchemaTagValue(jsonType string, value string) interface{} {
+	if jsonType == "string" || jsonType == "" {
+		return value
+	}
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(value), &parsed); err != nil {
+		return value
+	}
+	return parsed
+}
@@ -0,0 +1,164 @@
+package openrouterapigo
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// SSEEvent is a single Server-Sent Events message. A message made only of
+// comment lines is returned with Comment set and no data, so callers can
+// treat keep-alives such as ": OPENROUTER PROCESSING" as progress.
+type SSEEvent struct {
+	// Event is the event type, empty for the default "message" type.
+	Event string
+	// Data is the event payload. Multiple data lines are joined with "\n".
+	Data string
+	// ID is the last event id seen on the stream.
+	ID string
+	// Retry is the reconnection time in milliseconds, if the server set one.
+	Retry int
+	// Comment holds the text of comment lines, joined with "\n".
+	Comment string
+}
+
+// IsComment reports whether the event carries only comments.
+func (e SSEEvent) IsComment() bool {
+	return e.Comment != "" && e.Data == "" && e.Event == ""
+}
+
+// SSEDecoder reads Server-Sent Events from a stream following the HTML
+// specification: events are framed by blank lines, data may span several
+// lines, and LF, CR and CRLF line endings are accepted. Lines have no length
+// limit.
+type SSEDecoder struct {
+	reader  io.Reader
+	buf     []byte
+	start   int
+	scanned int
+	readErr error
+	lastID  string
+	started bool
+}
+
+// NewSSEDecoder returns a decoder reading from r.
+func NewSSEDecoder(r io.Reader) *SSEDecoder {
+	return &SSEDecoder{reader: r}
+}
+
+// Next returns the next event. Comments are returned as soon as a comment
+// line is read. It returns io.EOF once the stream ends; an unterminated
+// event at the end of the stream is discarded, as the specification requires.
+func (d *SSEDecoder) Next() (SSEEvent, error) {
+	var event SSEEvent
+	var data strings.Builder
+	hasData := false
+
+	for {
+		line, err := d.readLine()
+		if err != nil {
+			return SSEEvent{}, err
+		}
+
+		if line == "" {
+			if !hasData {
+				event = SSEEvent{}
+				continue
+			}
+			event.Data = data.String()
+			event.ID = d.lastID
+			return event, nil
+		}
+
+		if line[0] == ':' {
+			if hasData || event.Event != "" {
+				continue
+			}
+			return SSEEvent{Comment: strings.TrimPrefix(line[1:], " "), ID: d.lastID}, nil
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event.Event = value
+		case "data":
+			if hasData {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+			hasData = true
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				d.lastID = value
+			}
+		case "retry":
+			if retry, err := strconv.Atoi(value); err == nil && retry >= 0 {
+				event.Retry = retry
+			}
+		}
+	}
+}
+
+// readLine returns the next line without its terminator. A final line
+// without terminator is returned before the read error.
+func (d *SSEDecoder) readLine() (string, error) {
+	for {
+		pending := d.buf[d.start:]
+		// Bytes before scanned are known to hold no line ending, so long lines
+		// arriving in small reads are not searched again.
+		if i := bytes.IndexAny(pending[d.scanned:], "\r\n"); i >= 0 {
+			i += d.scanned
+			// A CR at the end of the buffer may be followed by the LF of a CRLF.
+			if pending[i] == '\r' && i+1 == len(pending) && d.readErr == nil {
+				d.scanned = i
+				d.fill()
+				continue
+			}
+			line := pending[:i]
+			consumed := i + 1
+			if pending[i] == '\r' && consumed < len(pending) && pending[consumed] == '\n' {
+				consumed++
+			}
+			d.start += consumed
+			d.scanned = 0
+			if !d.started {
+				d.started = true
+				line = bytes.TrimPrefix(line, []byte("\xEF\xBB\xBF"))
+			}
+			return string(line), nil
+		}
+
+		if d.readErr != nil {
+			if len(pending) > 0 {
+				d.start = len(d.buf)
+				d.scanned = 0
+				return string(pending), nil
+			}
+			return "", d.readErr
+		}
+		d.scanned = len(pending)
+		d.fill()
+	}
+}
+
+// fill reads more data into the buffer, moving unread bytes to its front and
+// growing it when full.
+func (d *SSEDecoder) fill() {
+	if d.start > 0 {
+		n := copy(d.buf, d.buf[d.start:])
+		d.buf = d.buf[:n]
+		d.start = 0
+	}
+	if len(d.buf) == cap(d.buf) {
+		grown := make([]byte, len(d.buf), 2*cap(d.buf)+4096)
+		copy(grown, d.buf)
+		d.buf = grown
+	}
+	n, err := d.reader.Read(d.buf[len(d.buf):cap(d.buf)])
+	d.buf = d.buf[:len(d.buf)+n]
+	if err != nil {
+		d.readErr = err
+	}
+}
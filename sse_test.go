@@ -0,0 +1,138 @@
+package openrouterapigo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func collectSSE(t *testing.T, r io.Reader) []SSEEvent {
+	t.Helper()
+	decoder := NewSSEDecoder(r)
+	events := []SSEEvent{}
+	for {
+		event, err := decoder.Next()
+		if err == io.EOF {
+			return events
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		events = append(events, event)
+	}
+}
+
+func TestSSEDecoder_Framing(t *testing.T) {
+	input := ": OPENROUTER PROCESSING\n\n" +
+		"event: update\nid: 7\nretry: 1500\ndata: first\ndata: second\n\n" +
+		"data:no-space\r\n\r\n" +
+		"data: cr only\r\r" +
+		"unknown: field\ndata: {\"a\":1}\n\n" +
+		"data: unterminated"
+
+	events := collectSSE(t, strings.NewReader(input))
+	if len(events) != 5 {
+		t.Fatalf("expected 5 events, got %d: %+v", len(events), events)
+	}
+
+	if !events[0].IsComment() || events[0].Comment != "OPENROUTER PROCESSING" {
+		t.Fatalf("unexpected comment event: %+v", events[0])
+	}
+	if events[1].Event != "update" || events[1].ID != "7" || events[1].Retry != 1500 || events[1].Data != "first\nsecond" {
+		t.Fatalf("unexpected named event: %+v", events[1])
+	}
+	if events[2].Data != "no-space" || events[2].ID != "7" {
+		t.Fatalf("unexpected CRLF event: %+v", events[2])
+	}
+	if events[3].Data != "cr only" {
+		t.Fatalf("unexpected CR event: %+v", events[3])
+	}
+	if events[4].Data != `{"a":1}` || events[4].Event != "" {
+		t.Fatalf("unexpected default event: %+v", events[4])
+	}
+}
+
+func TestSSEDecoder_LargePayloadAndSmallReads(t *testing.T) {
+	large := strings.Repeat("x", 1<<20)
+	input := "data: " + large + "\r\n\r\ndata: tail\r\n\r\n"
+
+	events := collectSSE(t, iotest.OneByteReader(strings.NewReader(input)))
+	if len(events) != 2 || events[0].Data != large || events[1].Data != "tail" {
+		t.Fatalf("unexpected events: %d", len(events))
+	}
+}
+
+func TestFetchChatCompletionsStream_SSEFraming(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, ": OPENROUTER PROCESSING\n\n")
+		fmt.Fprint(w, "event: message\nid: 1\ndata: {\"id\":\"a\",\ndata: \"choices\":[{\"delta\":{\"content\":\"Hi\"}}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client())
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	stream := client.StartChatCompletionsStream(Request{Model: "m", Stream: true}, ctx)
+	processing := 0
+	content := ""
+	for {
+		ev, ok := stream.Recv(ctx)
+		if ev.Err != nil {
+			t.Fatalf("unexpected error: %v", ev.Err)
+		}
+		if !ok {
+			break
+		}
+		if ev.Processing {
+			processing++
+		}
+		if ev.Response != nil {
+			content += ev.Response.Choices[0].Delta.Content
+		}
+	}
+	if processing != 1 || content != "Hi" {
+		t.Fatalf("unexpected stream: processing=%d content=%q", processing, content)
+	}
+}
+
+func TestFetchChatCompletionsStream_NotEventStream(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"a","choices":[{"message":{"role":"assistant","content":"Hi"}}]}`)
+	}))
+	defer server.Close()
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client(), WithRetryPolicy(RetryPolicy{MaxAttempts: 3}))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	stream := client.StartChatCompletionsStream(Request{Model: "m", Stream: true}, ctx)
+	var streamErr error
+	for {
+		ev, ok := stream.Recv(ctx)
+		if ev.Err != nil {
+			streamErr = ev.Err
+			break
+		}
+		if !ok {
+			break
+		}
+	}
+	if streamErr == nil || !strings.Contains(streamErr.Error(), "not an event stream") {
+		t.Fatalf("expected a non event stream error, got %v", streamErr)
+	}
+	if atomic.LoadInt32(&attempts) != 1 {
+		t.Fatalf("expected a single attempt, got %d", attempts)
+	}
+}
@@ -0,0 +1,203 @@
+package openrouterapigo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrStreamIdleTimeout is returned when a stream receives no bytes for
+	// longer than the idle timeout, see WithStreamIdleTimeout.
+	ErrStreamIdleTimeout = errors.New("openrouter: stream idle timeout")
+	// ErrFirstTokenTimeout is returned when a stream delivers no chunk within
+	// the first-token timeout, see WithFirstTokenTimeout.
+	ErrFirstTokenTimeout = errors.New("openrouter: first token timeout")
+)
+
+// streamWithRetry opens the stream built by newReq and forwards its chunks to
+// outputChan after passing them to onChunk. Failures before the first chunk
+// are retried according to the client retry policy.
+func streamWithRetry[T streamChunk](c *OpenRouterClient, ctx context.Context, newReq func(ctx context.Context) (*http.Request, error), outputChan chan T, processingChan chan interface{}, recorder *streamRecorder, onChunk func(*T) error) error {
+	return c.withRetry(ctx, func() error {
+		attemptCtx, stop, cancel := c.attemptContext(ctx)
+		defer cancel()
+		watchCtx, watchdog := c.watchStream(attemptCtx)
+		defer watchdog.stop()
+
+		req, err := newReq(watchCtx)
+		if err != nil {
+			return permanentError{err}
+		}
+
+		resp, err := c.send(req)
+		stop()
+		if err != nil {
+			return watchdog.timeoutError(ctx, timeoutError(attemptCtx, ctx, err))
+		}
+		defer resp.Body.Close()
+		resp.Body = watchdog.watchBody(resp.Body)
+
+		delivered, err := readStream(ctx, resp, outputChan, processingChan, recorder, func(chunk *T) error {
+			watchdog.gotChunk()
+			return onChunk(chunk)
+		})
+		err = watchdog.timeoutError(ctx, err)
+		if err != nil && delivered {
+			return permanentError{err}
+		}
+		return err
+	})
+}
+
+// streamWatchdog cancels a stream attempt that stalls, recording the reason as
+// the cancellation cause.
+type streamWatchdog struct {
+	ctx        context.Context
+	idle       time.Duration
+	idleTimer  *time.Timer
+	firstTimer *time.Timer
+	cancel     context.CancelCauseFunc
+}
+
+// watchStream derives the context of a stream attempt from the client stream
+// timeouts. The first-token timer starts right away.
+func (c *OpenRouterClient) watchStream(ctx context.Context) (context.Context, *streamWatchdog) {
+	watchCtx, cancel := context.WithCancelCause(ctx)
+	watchdog := &streamWatchdog{ctx: watchCtx, idle: c.streamIdleTimeout, cancel: cancel}
+	if timeout := c.firstTokenTimeout; timeout > 0 {
+		watchdog.firstTimer = time.AfterFunc(timeout, func() {
+			cancel(fmt.Errorf("%w: no chunk within %s", ErrFirstTokenTimeout, timeout))
+		})
+	}
+	return watchCtx, watchdog
+}
+
+// watchBody returns a body that runs the idle timer only while a read is
+// waiting for bytes, so time spent handing chunks to a slow consumer does not
+// count as idle.
+func (w *streamWatchdog) watchBody(body io.ReadCloser) io.ReadCloser {
+	if w.idle <= 0 {
+		return body
+	}
+	idle := w.idle
+	w.idleTimer = time.AfterFunc(idle, func() {
+		w.cancel(fmt.Errorf("%w: no data for %s", ErrStreamIdleTimeout, idle))
+	})
+	w.idleTimer.Stop()
+	return &idleResetBody{ReadCloser: body, watchdog: w}
+}
+
+func (w *streamWatchdog) gotChunk() {
+	if w.firstTimer != nil {
+		w.firstTimer.Stop()
+	}
+}
+
+func (w *streamWatchdog) stop() {
+	w.gotChunk()
+	if w.idleTimer != nil {
+		w.idleTimer.Stop()
+	}
+	w.cancel(context.Canceled)
+}
+
+// timeoutError replaces the error of an attempt cancelled by the watchdog with
+// the timeout cause, unless the parent context ended.
+func (w *streamWatchdog) timeoutError(parent context.Context, err error) error {
+	if err == nil || parent.Err() != nil {
+		return err
+	}
+	if cause := context.Cause(w.ctx); errors.Is(cause, ErrStreamIdleTimeout) || errors.Is(cause, ErrFirstTokenTimeout) {
+		return cause
+	}
+	return err
+}
+
+type idleResetBody struct {
+	io.ReadCloser
+	watchdog *streamWatchdog
+}
+
+func (b *idleResetBody) Read(p []byte) (int, error) {
+	b.watchdog.idleTimer.Reset(b.watchdog.idle)
+	defer b.watchdog.idleTimer.Stop()
+	return b.ReadCloser.Read(p)
+}
+
+// streamChunk is implemented by stream chunk types. streamError returns an
+// error object sent after the stream started; streamInfo reports what the
+// stream stats need.
+type streamChunk interface {
+	streamError() *ErrorResponse
+	streamInfo() (id string, model string, hasOutput bool, usage *ResponseUsage)
+}
+
+// readStream forwards the chunks of the response body to outputChan after
+// passing them to onChunk, and signals comments on processingChan. Chunks
+// carrying an error end the stream with an *APIError. It reports whether any
+// chunk was delivered, so failed streams can be retried only when the caller
+// has not seen partial output. A body ending without any data event, such as
+// a JSON document or an HTML error page, is not an event stream and fails.
+func readStream[T streamChunk](ctx context.Context, resp *http.Response, outputChan chan T, processingChan chan interface{}, recorder *streamRecorder, onChunk func(*T) error) (bool, error) {
+	delivered := false
+	sawData := false
+	decoder := NewSSEDecoder(resp.Body)
+	for {
+		if err := ctx.Err(); err != nil {
+			return delivered, err
+		}
+
+		event, err := decoder.Next()
+		if err != nil {
+			if err == io.EOF {
+				if !sawData {
+					return delivered, permanentError{fmt.Errorf("openrouter: response is not an event stream (Content-Type %q)", resp.Header.Get("Content-Type"))}
+				}
+				return delivered, nil
+			}
+			return delivered, err
+		}
+
+		if event.IsComment() {
+			recorder.processing()
+			select {
+			case processingChan <- true:
+			case <-ctx.Done():
+				return delivered, ctx.Err()
+			}
+			continue
+		}
+
+		sawData = true
+		payload := strings.TrimSpace(event.Data)
+		if payload == "" {
+			continue
+		}
+		if payload == "[DONE]" {
+			return delivered, nil
+		}
+		var chunk T
+		if err := json.Unmarshal([]byte(payload), &chunk); err != nil {
+			return delivered, permanentError{fmt.Errorf("invalid stream event %q: %w", event.Data, err)}
+		}
+		if errResp := chunk.streamError(); errResp != nil {
+			return delivered, newStreamAPIError(resp, errResp)
+		}
+		recorder.chunk(chunk)
+		if err := onChunk(&chunk); err != nil {
+			return delivered, err
+		}
+		select {
+		case outputChan <- chunk:
+			delivered = true
+		case <-ctx.Done():
+			return delivered, ctx.Err()
+		}
+	}
+}
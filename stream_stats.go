@@ -0,0 +1,132 @@
+package openrouterapigo
+
+import (
+	"sync"
+	"time"
+)
+
+// StreamStats holds the timing of a stream. Times that were not reached, e.g.
+// FirstProcessing for a stream without keep-alive comments, are zero.
+type StreamStats struct {
+	GenerationID string
+	Model        string
+	// Start is when the first request attempt was sent.
+	Start time.Time
+	// FirstProcessing is when the first keep-alive comment arrived.
+	FirstProcessing time.Time
+	// FirstToken and LastToken are when the first and last chunk carrying
+	// generated output arrived.
+	FirstToken time.Time
+	LastToken  time.Time
+	// End is when the stream finished, successfully or not. It is zero while
+	// the stream is running.
+	End time.Time
+	// Chunks is the number of chunks received.
+	Chunks int
+	// OutputTokens is the completion token count reported in the final usage
+	// chunk, zero when the stream sent no usage.
+	OutputTokens int
+	// TokensPerSecond is OutputTokens over the time between the first and the
+	// last token. It is set when the stream ends.
+	TokensPerSecond float64
+	// Err is the error that ended the stream, if any.
+	Err error
+}
+
+// TimeToFirstToken returns the time from Start to FirstToken, zero if no token
+// arrived.
+func (s StreamStats) TimeToFirstToken() time.Duration {
+	if s.FirstToken.IsZero() {
+		return 0
+	}
+	return s.FirstToken.Sub(s.Start)
+}
+
+// Duration returns the time from Start to End, zero while the stream is
+// running.
+func (s StreamStats) Duration() time.Duration {
+	if s.End.IsZero() {
+		return 0
+	}
+	return s.End.Sub(s.Start)
+}
+
+// StreamStatsHook receives the stats of every stream when it ends.
+type StreamStatsHook func(stats StreamStats)
+
+// WithStreamStatsHook calls hook with the stats of every chat and text
+// completion stream once it ends. The hook runs on the stream goroutine before
+// the stream channels are closed, so it should return quickly.
+func WithStreamStatsHook(hook StreamStatsHook) ClientOption {
+	return func(c *OpenRouterClient) {
+		c.streamStatsHook = hook
+	}
+}
+
+// streamRecorder collects StreamStats on the stream goroutine while the
+// consumer may read them.
+type streamRecorder struct {
+	mu    sync.Mutex
+	stats StreamStats
+}
+
+func newStreamRecorder() *streamRecorder {
+	return &streamRecorder{stats: StreamStats{Start: time.Now()}}
+}
+
+func (r *streamRecorder) processing() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stats.FirstProcessing.IsZero() {
+		r.stats.FirstProcessing = time.Now()
+	}
+}
+
+func (r *streamRecorder) chunk(chunk streamChunk) {
+	id, model, hasOutput, usage := chunk.streamInfo()
+	now := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stats.Chunks++
+	if r.stats.GenerationID == "" {
+		r.stats.GenerationID = id
+	}
+	if r.stats.Model == "" {
+		r.stats.Model = model
+	}
+	if hasOutput {
+		if r.stats.FirstToken.IsZero() {
+			r.stats.FirstToken = now
+		}
+		r.stats.LastToken = now
+	}
+	if usage != nil {
+		r.stats.OutputTokens = usage.CompletionTokens
+	}
+}
+
+func (r *streamRecorder) finish(err error) StreamStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stats.End = time.Now()
+	r.stats.Err = err
+	if elapsed := r.stats.LastToken.Sub(r.stats.FirstToken); r.stats.OutputTokens > 0 && elapsed > 0 {
+		r.stats.TokensPerSecond = float64(r.stats.OutputTokens) / elapsed.Seconds()
+	}
+	return r.stats
+}
+
+func (r *streamRecorder) snapshot() StreamStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stats
+}
+
+// finishStream records the end of a stream and passes its stats to the hook.
+func (c *OpenRouterClient) finishStream(recorder *streamRecorder, err error) {
+	stats := recorder.finish(err)
+	if c.streamStatsHook != nil {
+		c.streamStatsHook(stats)
+	}
+}
@@ -0,0 +1,90 @@
+package openrouterapigo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestChatCompletionsStreamStats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, ": OPENROUTER PROCESSING\n\n")
+		w.(http.Flusher).Flush()
+		time.Sleep(20 * time.Millisecond)
+		fmt.Fprint(w, "data: {\"id\":\"gen-1\",\"model\":\"m\",\"choices\":[{\"delta\":{\"role\":\"assistant\",\"content\":\"\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"id\":\"gen-1\",\"choices\":[{\"delta\":{\"content\":\"Hel\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		time.Sleep(20 * time.Millisecond)
+		fmt.Fprint(w, "data: {\"id\":\"gen-1\",\"choices\":[{\"delta\":{\"content\":\"lo\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"id\":\"gen-1\",\"choices\":[{\"finish_reason\":\"stop\",\"delta\":{\"content\":\"\"}}],\"usage\":{\"prompt_tokens\":3,\"completion_tokens\":4,\"total_tokens\":7}}\n\ndata: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	hooked := make(chan StreamStats, 1)
+	client := NewOpenRouterClientFull("key", server.URL, server.Client(), WithStreamStatsHook(func(stats StreamStats) {
+		hooked <- stats
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	stream := client.StartChatCompletionsStream(Request{Model: "m"}, ctx)
+	for {
+		ev, ok := stream.Recv(ctx)
+		if ev.Err != nil {
+			t.Fatalf("unexpected error: %v", ev.Err)
+		}
+		if !ok {
+			break
+		}
+	}
+
+	stats := stream.Stats()
+	if stats.GenerationID != "gen-1" || stats.Model != "m" || stats.Chunks != 4 || stats.OutputTokens != 4 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if stats.FirstProcessing.IsZero() || !stats.FirstProcessing.Before(stats.FirstToken) {
+		t.Fatalf("processing must be recorded before the first token: %+v", stats)
+	}
+	if stats.TimeToFirstToken() < 20*time.Millisecond || stats.LastToken.Sub(stats.FirstToken) < 20*time.Millisecond {
+		t.Fatalf("unexpected timing: %+v", stats)
+	}
+	if stats.End.IsZero() || stats.TokensPerSecond <= 0 || stats.TokensPerSecond > 200 {
+		t.Fatalf("unexpected final stats: %+v", stats)
+	}
+
+	select {
+	case got := <-hooked:
+		if got.GenerationID != stats.GenerationID || got.End != stats.End {
+			t.Fatalf("hook got different stats: %+v", got)
+		}
+	default:
+		t.Fatal("stats hook was not called before the stream ended")
+	}
+}
+
+func TestStreamStats_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusPaymentRequired)
+		fmt.Fprint(w, `{"error":{"code":402,"message":"no credits"}}`)
+	}))
+	defer server.Close()
+
+	var hooked StreamStats
+	client := NewOpenRouterClientFull("key", server.URL, server.Client(), WithStreamStatsHook(func(stats StreamStats) {
+		hooked = stats
+	}))
+
+	outputChan := make(chan CompletionResponse)
+	processingChan := make(chan interface{})
+	errChan := make(chan error)
+	client.FetchCompletionsStream(CompletionRequest{Model: "m"}, outputChan, processingChan, errChan, context.Background())
+	if err := <-errChan; !IsInsufficientCredits(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !IsInsufficientCredits(hooked.Err) || hooked.Chunks != 0 || hooked.TimeToFirstToken() != 0 {
+		t.Fatalf("unexpected stats: %+v", hooked)
+	}
+}
@@ -0,0 +1,358 @@
+package openrouterapigo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// JSONSchemaFormat returns a json_schema response format for T, with the
+// schema generated from the struct the same way as tool arguments.
+//
+// Strict mode requires every property to be listed as required, so with
+// strict set optional fields are made required and nullable instead. Types
+// strict mode cannot describe, such as maps and interfaces, turn strict off.
+func JSONSchemaFormat[T any](name string, strict bool) *ResponseFormat {
+	var zero T
+	schema := generateSchema(zero)
+	if strict {
+		schema, strict = strictSchema(schema)
+	}
+	return &ResponseFormat{
+		Type: ResponseFormatJSONSchema,
+		JSONSchema: &JSONSchema{
+			Name:   name,
+			Strict: strict,
+			Schema: schema,
+		},
+	}
+}
+
+// CompleteStructured asks the model for a response matching the schema of T,
+// then validates and decodes it. The agent config is used as is, except for
+// the response format. A response that does not match the schema is returned
+// together with the validation errors, see ValidationError.
+func CompleteStructured[T any](ctx context.Context, agent *RouterAgent, messages []MessageRequest) (T, *Response, error) {
+	var result T
+	format := JSONSchemaFormat[T](schemaName[T](), true)
+
+	request := agent.newRequest(false)
+	request.Messages = messages
+	request.ResponseFormat = format
+
+	response, err := agent.client.FetchChatCompletionsContext(ctx, request)
+	if err != nil {
+		return result, nil, err
+	}
+	if len(response.Choices) == 0 || response.Choices[0].Message == nil {
+		return result, response, fmt.Errorf("structured output: empty response")
+	}
+
+	result, err = decodeStructured[T](response.Choices[0].Message.Content)
+	return result, response, err
+}
+
+// ChatTyped runs the tool loop of agent for messageInput like ChatContext,
+// with the response format set to the schema of T, and decodes the final
+// assistant message into T. The model can call tools as usual before its
+// final answer. The transcript is returned and appended to agent.Messages
+// even when the final answer does not match the schema.
+func ChatTyped[T any](ctx context.Context, agent *RouterAgentChat, messageInput string) (T, []message, error) {
+	var result T
+	format := JSONSchemaFormat[T](schemaName[T](), true)
+
+	newMessages, err := agent.runToolLoop(ctx, []message{
+		MessageRequest{
+			Role:    RoleUser,
+			Content: TextContent(messageInput),
+		},
+	}, toolLoopOptions{responseFormat: format})
+	if err != nil {
+		return result, nil, err
+	}
+
+	var content strings.Builder
+	for _, part := range newMessages[len(newMessages)-1].GetContentPart() {
+		content.WriteString(part.Text)
+	}
+	result, err = decodeStructured[T](content.String())
+	return result, newMessages, err
+}
+
+// decodeStructured validates content against the schema of T and decodes it
+// into T. The generated schema is used rather than its strict form, so
+// answers omitting optional fields are accepted too.
+func decodeStructured[T any](content string) (T, error) {
+	var result T
+	schema := generateSchema(result)
+	data := []byte(stripCodeFence(content))
+
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return result, fmt.Errorf("structured output: invalid JSON: %w", err)
+	}
+	if err := validateSchema(schema, value); err != nil {
+		return result, fmt.Errorf("structured output: %w", err)
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return result, fmt.Errorf("structured output: %w", err)
+	}
+	return result, nil
+}
+
+var schemaNamePattern = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// schemaName derives the json_schema name from the Go type name.
+func schemaName[T any]() string {
+	name := schemaNamePattern.ReplaceAllString(reflect.TypeOf((*T)(nil)).Elem().Name(), "_")
+	if name == "" {
+		return "response"
+	}
+	return name
+}
+
+// stripCodeFence removes a markdown code fence some models wrap JSON in.
+func stripCodeFence(content string) string {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "```") {
+		return content
+	}
+	content = strings.TrimPrefix(content, "```")
+	if newline := strings.IndexByte(content, '\n'); newline >= 0 {
+		content = content[newline+1:]
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(content), "```"))
+}
+
+// ValidationError reports a value that does not match its JSON schema.
+type ValidationError struct {
+	// Path locates the value, e.g. "$.items[0].name".
+	Path string `json:"path"`
+	// Message describes the mismatch.
+	Message string `json:"message"`
+	// Expected is the expected JSON type, if the type did not match.
+	Expected string `json:"expected,omitempty"`
+	// Allowed lists the allowed values, if the value is not in an enum.
+	Allowed []interface{} `json:"allowed,omitempty"`
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// validationErrors returns the ValidationErrors joined in err.
+func validationErrors(err error) []*ValidationError {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var errs []*ValidationError
+		for _, err := range joined.Unwrap() {
+			errs = append(errs, validationErrors(err)...)
+		}
+		return errs
+	}
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return []*ValidationError{validationErr}
+	}
+	return nil
+}
+
+// validateSchema checks a decoded JSON value against the subset of JSON
+// schema produced by generateSchema: type, properties, required,
+// additionalProperties, items, enum, numeric bounds, string constraints and
+// local $ref. Optional properties also accept null. All mismatches are joined.
+func validateSchema(schema map[string]interface{}, value interface{}) error {
+	v := schemaValidator{root: schema}
+	return errors.Join(v.validate(schema, value, "$")...)
+}
+
+type schemaValidator struct {
+	root map[string]interface{}
+}
+
+// resolve follows a local $ref to the root schema or one of its $defs.
+func (v schemaValidator) resolve(schema map[string]interface{}) map[string]interface{} {
+	for depth := 0; depth < 32; depth++ {
+		ref, ok := schema["$ref"].(string)
+		if !ok {
+			return schema
+		}
+		if ref == "#" {
+			schema = v.root
+			continue
+		}
+		defs, _ := v.root["$defs"].(map[string]interface{})
+		def, _ := defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]interface{})
+		schema = def
+	}
+	return schema
+}
+
+func (v schemaValidator) validate(schema map[string]interface{}, value interface{}, path string) []error {
+	schema = v.resolve(schema)
+	if len(schema) == 0 {
+		return nil
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok && !containsValue(enum, value) {
+		return []error{&ValidationError{Path: path, Message: fmt.Sprintf("value %v is not allowed", value), Allowed: enum}}
+	}
+
+	types := schemaTypes(schema["type"])
+	if len(types) > 0 {
+		actual := jsonType(value)
+		matched := false
+		for _, expected := range types {
+			if actual == expected || (expected == "number" && actual == "integer") {
+				matched = true
+			}
+		}
+		if !matched {
+			expected := strings.Join(types, " or ")
+			return []error{&ValidationError{Path: path, Message: fmt.Sprintf("expected %s, got %s", expected, actual), Expected: expected}}
+		}
+	}
+
+	var errs []error
+	switch value := value.(type) {
+	case float64:
+		if minimum, ok := schemaNumber(schema["minimum"]); ok && value < minimum {
+			errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("value %v is less than the minimum %v", value, minimum)})
+		}
+		if maximum, ok := schemaNumber(schema["maximum"]); ok && value > maximum {
+			errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("value %v is greater than the maximum %v", value, maximum)})
+		}
+	case string:
+		length := utf8.RuneCountInString(value)
+		if minLength, ok := schemaNumber(schema["minLength"]); ok && float64(length) < minLength {
+			errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("length %d is less than the minimum length %v", length, minLength)})
+		}
+		if maxLength, ok := schemaNumber(schema["maxLength"]); ok && float64(length) > maxLength {
+			errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("length %d is greater than the maximum length %v", length, maxLength)})
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(value) {
+				errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("value does not match the pattern %q", pattern)})
+			}
+		}
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		required := map[string]bool{}
+		for _, name := range schemaStrings(schema["required"]) {
+			required[name] = true
+			if _, ok := value[name]; !ok {
+				errs = append(errs, &ValidationError{Path: path + "." + name, Message: "required property is missing"})
+			}
+		}
+		for name, child := range value {
+			// encoding/json treats null like an absent property, so optional
+			// fields such as pointers accept it.
+			if child == nil && !required[name] {
+				continue
+			}
+			propertySchema, ok := properties[name].(map[string]interface{})
+			if !ok {
+				if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+					errs = append(errs, &ValidationError{Path: path + "." + name, Message: "property is not allowed"})
+				} else if additional, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+					errs = append(errs, v.validate(additional, child, path+"."+name)...)
+				}
+				continue
+			}
+			errs = append(errs, v.validate(propertySchema, child, path+"."+name)...)
+		}
+	case []interface{}:
+		items, _ := schema["items"].(map[string]interface{})
+		for i, child := range value {
+			errs = append(errs, v.validate(items, child, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+	return errs
+}
+
+// schemaNumber reads a numeric keyword from a generated or decoded schema.
+func schemaNumber(value interface{}) (float64, bool) {
+	switch value := value.(type) {
+	case float64:
+		return value, true
+	case int:
+		return float64(value), true
+	}
+	return 0, false
+}
+
+// jsonType returns the JSON schema type of a value decoded by encoding/json.
+func jsonType(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if value == math.Trunc(value) && !math.IsInf(value, 0) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// schemaTypes reads a type keyword, which is a string or a list of strings.
+func schemaTypes(value interface{}) []string {
+	if single, ok := value.(string); ok {
+		return []string{single}
+	}
+	return schemaStrings(value)
+}
+
+// schemaStrings reads a list of strings from a generated ([]string) or
+// decoded ([]interface{}) schema.
+func schemaStrings(value interface{}) []string {
+	switch value := value.(type) {
+	case []string:
+		return value
+	case []interface{}:
+		strs := make([]string, 0, len(value))
+		for _, item := range value {
+			if str, ok := item.(string); ok {
+				strs = append(strs, str)
+			}
+		}
+		return strs
+	}
+	return nil
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, allowed := range values {
+		if reflect.DeepEqual(normalizeJSON(allowed), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeJSON converts Go numbers to float64 so enum values from generated
+// schemas compare equal to decoded JSON.
+func normalizeJSON(value interface{}) interface{} {
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	}
+	return value
+}
@@ -0,0 +1,200 @@
+package openrouterapigo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type extractedPerson struct {
+	Name    string   `json:"name"`
+	Age     int      `json:"age"`
+	Emails  []string `json:"emails,omitempty"`
+	Address struct {
+		City string `json:"city"`
+	} `json:"address"`
+}
+
+func TestCompleteStructured(t *testing.T) {
+	var got Request
+	content := `{"name":"Ada","age":36,"address":{"city":"London"}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		message, _ := json.Marshal(content)
+		fmt.Fprintf(w, `{"choices":[{"message":{"role":"assistant","content":%s}}]}`, message)
+	}))
+	defer server.Close()
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client())
+	agent := NewRouterAgent(client, "m", RouterAgentConfig{Temperature: 0.1})
+
+	person, resp, err := CompleteStructured[extractedPerson](context.Background(), agent, []MessageRequest{{Role: RoleUser, Content: TextContent("Ada, 36, London")}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if person.Name != "Ada" || person.Age != 36 || person.Address.City != "London" || resp == nil {
+		t.Fatalf("unexpected result: %+v", person)
+	}
+
+	format := got.ResponseFormat
+	if format == nil || format.Type != ResponseFormatJSONSchema || format.JSONSchema == nil {
+		t.Fatalf("unexpected response format: %+v", format)
+	}
+	if format.JSONSchema.Name != "extractedPerson" || !format.JSONSchema.Strict {
+		t.Fatalf("unexpected json schema: %+v", format.JSONSchema)
+	}
+	if props, _ := format.JSONSchema.Schema["properties"].(map[string]interface{}); len(props) != 4 {
+		t.Fatalf("unexpected schema properties: %+v", format.JSONSchema.Schema)
+	}
+	if got.Temperature != 0.1 {
+		t.Fatal("agent config must be kept")
+	}
+
+	content = "```json\n" + `{"name":"Ada","age":"36","address":{}}` + "\n```"
+	_, resp, err = CompleteStructured[extractedPerson](context.Background(), agent, nil)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || resp == nil {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	for _, want := range []string{"$.age: expected integer, got string", "$.address.city: required property is missing"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q in %v", want, err)
+		}
+	}
+}
+
+func TestJSONSchemaFormat_Strict(t *testing.T) {
+	type Answer struct {
+		Text   string  `json:"text"`
+		Mode   *string `json:"mode" enum:"short,long"`
+		Source *struct {
+			URL string `json:"url"`
+		} `json:"source,omitempty"`
+	}
+
+	schema := JSONSchemaFormat[Answer]("answer", true).JSONSchema
+	data, _ := json.Marshal(schema)
+	want := `{"name":"answer","strict":true,"schema":{"additionalProperties":false,"properties":{` +
+		`"mode":{"enum":["short","long",null],"type":["string","null"]},` +
+		`"source":{"additionalProperties":false,"properties":{"url":{"type":"string"}},"required":["url"],"type":["object","null"]},` +
+		`"text":{"type":"string"}},"required":["text","mode","source"],"type":"object"}}`
+	if string(data) != want {
+		t.Fatalf("unexpected strict schema:\n got %s\nwant %s", data, want)
+	}
+	if required := JSONSchemaFormat[Answer]("answer", false).JSONSchema.Schema["required"]; len(required.([]string)) != 1 {
+		t.Fatalf("non-strict schema must keep optional fields optional, got %v", required)
+	}
+
+	type Tagged struct {
+		Labels map[string]string `json:"labels"`
+	}
+	format := JSONSchemaFormat[Tagged]("tagged", true).JSONSchema
+	if format.Strict {
+		t.Fatal("expected strict to be turned off for a schema with a map")
+	}
+	if labels := format.Schema["properties"].(map[string]interface{})["labels"].(map[string]interface{}); labels["additionalProperties"] == nil {
+		t.Fatalf("expected the generated map schema, got %v", labels)
+	}
+
+	answer, err := decodeStructured[Answer](`{"text":"hi","mode":null,"source":null}`)
+	if err != nil || answer.Text != "hi" || answer.Mode != nil {
+		t.Fatalf("expected strict answer with nulls to decode, got %+v, %v", answer, err)
+	}
+	if _, err := decodeStructured[Answer](`{"text":"hi"}`); err != nil {
+		t.Fatalf("expected answer without optional fields to decode, got %v", err)
+	}
+}
+
+func TestValidateSchema(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"mode":  map[string]interface{}{"type": "string", "enum": []interface{}{"fast", "slow"}},
+			"score": map[string]interface{}{"type": "number"},
+			"tags":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		},
+		"required":             []string{"mode"},
+		"additionalProperties": false,
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		path  string
+	}{
+		{"valid", `{"mode":"fast","score":1,"tags":["a"]}`, ""},
+		{"enum", `{"mode":"medium"}`, "$.mode"},
+		{"item type", `{"mode":"fast","tags":["a",2]}`, "$.tags[1]"},
+		{"extra property", `{"mode":"fast","other":1}`, "$.other"},
+		{"not an object", `[]`, "$"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value interface{}
+			json.Unmarshal([]byte(tt.value), &value)
+			err := validateSchema(schema, value)
+			if tt.path == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Path != tt.path {
+				t.Fatalf("expected error at %s, got %v", tt.path, err)
+			}
+			if tt.name == "enum" && len(validationErr.Allowed) != 2 {
+				t.Fatalf("expected allowed values, got %+v", validationErr)
+			}
+		})
+	}
+}
+
+func TestChatTyped(t *testing.T) {
+	var requests []Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := Request{}
+		json.NewDecoder(r.Body).Decode(&request)
+		requests = append(requests, request)
+		if len(requests) == 1 {
+			fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"","tool_calls":[{"id":"1","type":"function","function":{"name":"lookup","arguments":"{\"name\":\"Ada\"}"}}]}}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"{\"name\":\"Ada\",\"age\":36,\"address\":{\"city\":\"London\"}}"}}]}`)
+	}))
+	defer server.Close()
+
+	client := NewOpenRouterClientFull("key", server.URL, server.Client())
+	agent := NewRouterAgentChat(client, "m", RouterAgentConfig{}, "system")
+	type lookupArgs struct {
+		Name string `json:"name"`
+	}
+	err := AddToolToAgent(&agent, ToolDefinition[lookupArgs]{
+		Name:     "lookup",
+		Function: func(a lookupArgs) any { return a.Name + " is 36 and lives in London" },
+	})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+
+	person, transcript, err := ChatTyped[extractedPerson](context.Background(), &agent, "Who is Ada?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if person.Name != "Ada" || person.Age != 36 || person.Address.City != "London" {
+		t.Fatalf("unexpected result: %+v", person)
+	}
+	if len(transcript) != 4 || len(agent.Messages) != 5 {
+		t.Fatalf("unexpected transcript: %d new, %d total", len(transcript), len(agent.Messages))
+	}
+	for i, request := range requests {
+		if request.ResponseFormat == nil || request.ResponseFormat.JSONSchema == nil || len(request.Tools) != 1 {
+			t.Fatalf("request %d must carry the schema and the tools: %+v", i, request)
+		}
+	}
+}
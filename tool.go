@@ -1,6 +1,7 @@
 package openrouterapigo
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"reflect"
@@ -24,16 +25,63 @@ type ToolInterface interface {
 	Metadata() FunctionDescription
 }
 
+// ContextToolInterface is implemented by tools that need the context of the
+// chat call running them, e.g. to stop work when the caller goes away.
+type ContextToolInterface interface {
+	ToolInterface
+	CallContext(ctx context.Context, args json.RawMessage) (any, error)
+}
+
 type toolWrapper[T any] struct {
 	definition ToolDefinition[T]
 }
 
 func (tw toolWrapper[T]) Call(args json.RawMessage) (any, error) {
+	input, err := decodeToolArguments[T](args, tw.Metadata().Parameters)
+	if err != nil {
+		return nil, err
+	}
+	return tw.definition.Function(input), nil
+}
+
+// ToolArgumentsError is returned when the arguments of a tool call do not
+// match the tool schema. RouterAgentChat sends the errors back to the model so
+// it can correct the call.
+type ToolArgumentsError struct {
+	Errors []*ValidationError
+}
+
+func (e *ToolArgumentsError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return "invalid arguments: " + strings.Join(messages, "; ")
+}
+
+func (e *ToolArgumentsError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// decodeToolArguments validates args against the tool schema and decodes
+// them into T.
+func decodeToolArguments[T any](args json.RawMessage, schema map[string]interface{}) (T, error) {
 	var input T
+	var value interface{}
+	if err := json.Unmarshal(args, &value); err != nil {
+		return input, &ToolArgumentsError{Errors: []*ValidationError{{Path: "$", Message: fmt.Sprintf("invalid JSON: %s", err)}}}
+	}
+	if err := validateSchema(schema, value); err != nil {
+		return input, &ToolArgumentsError{Errors: validationErrors(err)}
+	}
 	if err := json.Unmarshal(args, &input); err != nil {
-		return nil, fmt.Errorf("invalid arguments: %w", err)
+		return input, &ToolArgumentsError{Errors: []*ValidationError{{Path: "$", Message: err.Error()}}}
 	}
-	return tw.definition.Function(input), nil
+	return input, nil
 }
 
 func (tw toolWrapper[T]) Metadata() FunctionDescription {
@@ -45,107 +93,47 @@ func (tw toolWrapper[T]) Metadata() FunctionDescription {
 	}
 }
 
-func generateSchema(input any) map[string]interface{} {
-	schema := map[string]interface{}{
-		"type":       "object",
-		"properties": map[string]interface{}{},
-		"required":   []string{},
-	}
-
-	t := reflect.TypeOf(input)
-	if t.Kind() != reflect.Struct {
-		return map[string]interface{}{}
-	}
-
-	// Process struct fields
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		jsonTag := field.Tag.Get("json")
-		if jsonTag == "" || jsonTag == "-" {
-			continue
-		}
-
-		// Extract JSON field name
-		fieldName := strings.Split(jsonTag, ",")[0]
-		if fieldName == "" {
-			fieldName = field.Name
-		}
-
-		// Create property schema
-		propSchema := map[string]interface{}{}
-		fieldType := field.Type
-
-		// Handle different types
-		switch fieldType.Kind() {
-		case reflect.String:
-			propSchema["type"] = "string"
-
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
-			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			propSchema["type"] = "integer"
-
-		case reflect.Float32, reflect.Float64:
-			propSchema["type"] = "number"
-
-		case reflect.Bool:
-			propSchema["type"] = "boolean"
-
-		case reflect.Struct:
-			// Recursive call for nested structs
-			nested := reflect.New(fieldType).Elem().Interface()
-			propSchema = generateSchema(nested)
-
-		case reflect.Slice, reflect.Array:
-			propSchema["type"] = "array"
-			elemType := fieldType.Elem()
-
-			// Handle slice of structs
-			if elemType.Kind() == reflect.Struct {
-				nested := reflect.New(elemType).Elem().Interface()
-				propSchema["items"] = generateSchema(nested)
-			} else {
-				// Handle basic types in slices
-				propSchema["items"] = map[string]interface{}{
-					"type": goTypeToJSONType(elemType.Kind()),
-				}
-			}
-
-		default:
-			propSchema["type"] = "object"
-		}
+// ContextToolDefinition defines a tool that receives the context of the chat
+// call running it and can fail. The error is sent to the model as the tool
+// result. The schema of R is appended to the description, so the model knows
+// what the tool returns.
+type ContextToolDefinition[T any, R any] struct {
+	Function    func(context.Context, T) (R, error)
+	Name        string
+	Description string
+}
 
-		// Add description from struct tag if available
-		if desc := field.Tag.Get("jsonschema"); desc != "" {
-			propSchema["description"] = desc
-		} else if desc := field.Tag.Get("desc"); desc != "" {
-			propSchema["description"] = desc
-		}
+type contextToolWrapper[T any, R any] struct {
+	definition ContextToolDefinition[T, R]
+}
 
-		// Add to properties
-		schema["properties"].(map[string]interface{})[fieldName] = propSchema
+func (tw contextToolWrapper[T, R]) Call(args json.RawMessage) (any, error) {
+	return tw.CallContext(context.Background(), args)
+}
 
-		// Check if required
-		if !strings.Contains(jsonTag, "omitempty") {
-			schema["required"] = append(schema["required"].([]string), fieldName)
-		}
+func (tw contextToolWrapper[T, R]) CallContext(ctx context.Context, args json.RawMessage) (any, error) {
+	input, err := decodeToolArguments[T](args, tw.Metadata().Parameters)
+	if err != nil {
+		return nil, err
 	}
-
-	return schema
+	result, err := tw.definition.Function(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
-func goTypeToJSONType(kind reflect.Kind) string {
-	switch kind {
-	case reflect.String:
-		return "string"
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
-		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return "integer"
-	case reflect.Float32, reflect.Float64:
-		return "number"
-	case reflect.Bool:
-		return "boolean"
-	default:
-		return "object"
+func (tw contextToolWrapper[T, R]) Metadata() FunctionDescription {
+	var input T
+	description := tw.definition.Description
+	if result := generateTypeSchema(reflect.TypeOf((*R)(nil)).Elem()); len(result) > 0 {
+		resultSchema, _ := json.Marshal(result)
+		description = strings.TrimSpace(description + "\n\nReturns JSON matching this schema: " + string(resultSchema))
+	}
+	return FunctionDescription{
+		Description: description,
+		Name:        tw.definition.Name,
+		Parameters:  generateSchema(input),
 	}
 }
 
@@ -188,9 +176,25 @@ func (r *ToolRegistry) GenerateTools() ([]Tool, error) {
 }
 
 func (r *ToolRegistry) CallTool(name string, args json.RawMessage) (string, error) {
+	return r.CallToolContext(context.Background(), name, args)
+}
+
+// CallToolContext calls the named tool, passing ctx to tools implementing
+// ContextToolInterface. It returns ctx.Err() without calling the tool if ctx
+// is already done.
+func (r *ToolRegistry) CallToolContext(ctx context.Context, name string, args json.RawMessage) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
 	for _, tool := range r.tools {
 		if tool.Metadata().Name == name {
-			returnedValue, err := tool.Call(args)
+			var returnedValue any
+			var err error
+			if contextTool, ok := tool.(ContextToolInterface); ok {
+				returnedValue, err = contextTool.CallContext(ctx, args)
+			} else {
+				returnedValue, err = tool.Call(args)
+			}
 			if err != nil {
 				return "", err
 			}
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestGenerateSchema_EmptyStruct(t *testing.T) {
@@ -320,3 +322,200 @@ func TestToolRegistry_RegisterValidation(t *testing.T) {
 		t.Errorf("expected error for nil parameters schema")
 	}
 }
+
+type schemaBase struct {
+	ID string `json:"id"`
+}
+
+type schemaNode struct {
+	Value    int           `json:"value"`
+	Children []*schemaNode `json:"children,omitempty"`
+}
+
+type schemaTree struct {
+	Root schemaNode `json:"root"`
+}
+
+func TestGenerateSchema_RichTypes(t *testing.T) {
+	input := struct {
+		schemaBase
+		Nickname *string        `json:"nickname"`
+		Labels   map[string]int `json:"labels"`
+		Created  time.Time      `json:"created"`
+		Blob     []byte         `json:"blob"`
+		Extra    interface{}    `json:"extra"`
+		Mode     string         `json:"mode" enum:"fast,slow" default:"fast"`
+		Level    int            `json:"level" enum:"1,2,3"`
+		Ratio    float64        `json:"ratio" minimum:"0" maximum:"1" examples:"0.5,0.9"`
+		Code     string         `json:"code" minLength:"2" maxLength:"4" pattern:"^[A-Z]+$"`
+		Tags     []string       `json:"tags" enum:"a,b"`
+		Nested   map[string]*struct {
+			X bool `json:"x"`
+		} `json:"nested,omitempty"`
+	}{}
+	schema := generateSchema(input)
+	props := schema["properties"].(map[string]interface{})
+	prop := func(name string) map[string]interface{} {
+		return props[name].(map[string]interface{})
+	}
+
+	if _, ok := props["id"]; !ok {
+		t.Fatal("expected embedded fields to be flattened")
+	}
+	if prop("nickname")["type"] != "string" {
+		t.Errorf("expected pointer to use the element schema, got %v", prop("nickname"))
+	}
+	labels := prop("labels")
+	if labels["type"] != "object" || labels["additionalProperties"].(map[string]interface{})["type"] != "integer" {
+		t.Errorf("unexpected map schema: %v", labels)
+	}
+	if prop("created")["format"] != "date-time" || prop("blob")["type"] != "string" || len(prop("extra")) != 0 {
+		t.Errorf("unexpected special type schemas: %v %v %v", prop("created"), prop("blob"), prop("extra"))
+	}
+	if !reflect.DeepEqual(prop("mode")["enum"], []interface{}{"fast", "slow"}) || prop("mode")["default"] != "fast" {
+		t.Errorf("unexpected enum schema: %v", prop("mode"))
+	}
+	if !reflect.DeepEqual(prop("level")["enum"], []interface{}{float64(1), float64(2), float64(3)}) {
+		t.Errorf("expected numeric enum values, got %v", prop("level")["enum"])
+	}
+	ratio := prop("ratio")
+	if ratio["minimum"] != float64(0) || ratio["maximum"] != float64(1) || !reflect.DeepEqual(ratio["examples"], []interface{}{0.5, 0.9}) {
+		t.Errorf("unexpected bounds: %v", ratio)
+	}
+	code := prop("code")
+	if code["minLength"] != 2 || code["maxLength"] != 4 || code["pattern"] != "^[A-Z]+$" {
+		t.Errorf("unexpected string constraints: %v", code)
+	}
+	if items := prop("tags")["items"].(map[string]interface{}); !reflect.DeepEqual(items["enum"], []interface{}{"a", "b"}) {
+		t.Errorf("expected enum on array items, got %v", prop("tags"))
+	}
+
+	required := schema["required"].([]string)
+	for _, name := range required {
+		if name == "nickname" || name == "nested" {
+			t.Errorf("%s must be optional", name)
+		}
+	}
+	if required[0] != "id" {
+		t.Errorf("expected embedded required fields, got %v", required)
+	}
+
+	data, _ := json.Marshal(schema)
+	var decoded map[string]interface{}
+	json.Unmarshal(data, &decoded)
+	valid := `{"id":"1","labels":{"a":1},"created":"2024-01-01T00:00:00Z","blob":"","extra":null,"mode":"slow","level":2,"ratio":0.5,"code":"AB","tags":["a"]}`
+	var value interface{}
+	json.Unmarshal([]byte(valid), &value)
+	if err := validateSchema(decoded, value); err != nil {
+		t.Errorf("expected valid value, got %v", err)
+	}
+	withNull := `{"id":"1","nickname":null,"labels":{},"created":"2024-01-01T00:00:00Z","blob":"","extra":null,"mode":"fast","level":1,"ratio":0,"code":"AB","tags":[],"nested":null}`
+	json.Unmarshal([]byte(withNull), &value)
+	if err := validateSchema(decoded, value); err != nil {
+		t.Errorf("expected null to be accepted for optional fields, got %v", err)
+	}
+	json.Unmarshal([]byte(`{"id":null}`), &value)
+	if err := validateSchema(decoded, value); err == nil || !strings.Contains(err.Error(), "$.id: expected string, got null") {
+		t.Errorf("expected null to be rejected for required fields, got %v", err)
+	}
+	invalid := `{"id":"1","labels":{"a":"x"},"created":"","blob":"","extra":1,"mode":"slow","level":4,"ratio":2,"code":"abcde","tags":["c"]}`
+	json.Unmarshal([]byte(invalid), &value)
+	err := validateSchema(decoded, value)
+	for _, path := range []string{"$.labels.a", "$.level", "$.ratio", "$.code", "$.tags[0]"} {
+		if err == nil || !strings.Contains(err.Error(), path+":") {
+			t.Errorf("expected error at %s, got %v", path, err)
+		}
+	}
+}
+
+func TestGenerateSchema_EmbeddedConflicts(t *testing.T) {
+	input := struct {
+		schemaBase
+		ID string `json:"id,omitempty" desc:"outer"`
+	}{}
+	schema := generateSchema(input)
+	props := schema["properties"].(map[string]interface{})
+
+	if len(props) != 1 || props["id"].(map[string]interface{})["description"] != "outer" {
+		t.Fatalf("expected only the outer id, got %v", props)
+	}
+	if required := schema["required"].([]string); len(required) != 0 {
+		t.Fatalf("expected the outer optional id to win, got required %v", required)
+	}
+
+	var value interface{}
+	json.Unmarshal([]byte(`{}`), &value)
+	if err := validateSchema(schema, value); err != nil {
+		t.Fatalf("expected arguments without id to be valid, got %v", err)
+	}
+}
+
+func TestGenerateSchema_Recursive(t *testing.T) {
+	schema := generateSchema(schemaTree{})
+	root := schema["properties"].(map[string]interface{})["root"].(map[string]interface{})
+	if root["$ref"] != "#/$defs/schemaNode" {
+		t.Fatalf("expected $ref to the recursive type, got %v", root)
+	}
+	defs := schema["$defs"].(map[string]interface{})
+	node := defs["schemaNode"].(map[string]interface{})
+	children := node["properties"].(map[string]interface{})["children"].(map[string]interface{})
+	if children["items"].(map[string]interface{})["$ref"] != "#/$defs/schemaNode" {
+		t.Fatalf("unexpected recursive definition: %v", node)
+	}
+
+	self := generateSchema(&schemaNode{})
+	if self["type"] != "object" {
+		t.Fatalf("expected pointer to struct at top level, got %v", self)
+	}
+	items := self["properties"].(map[string]interface{})["children"].(map[string]interface{})["items"].(map[string]interface{})
+	if items["$ref"] != "#" {
+		t.Fatalf("expected reference to the root, got %v", items)
+	}
+
+	var value interface{}
+	json.Unmarshal([]byte(`{"root":{"value":1,"children":[{"value":"x"}]}}`), &value)
+	if err := validateSchema(schema, value); err == nil || !strings.Contains(err.Error(), "$.root.children[0].value") {
+		t.Fatalf("expected validation through $ref, got %v", err)
+	}
+}
+
+func TestToolWrapper_ValidatesArguments(t *testing.T) {
+	type Args struct {
+		Query string `json:"query"`
+		Sort  string `json:"sort,omitempty" enum:"relevance,date"`
+	}
+	called := false
+	wrapper := toolWrapper[Args]{
+		definition: ToolDefinition[Args]{
+			Name:     "search",
+			Function: func(args Args) any { called = true; return nil },
+		},
+	}
+
+	tests := []struct {
+		name string
+		args string
+		path string
+	}{
+		{"missing required", `{"sort":"date"}`, "$.query"},
+		{"enum", `{"query":"go","sort":"name"}`, "$.sort"},
+		{"extra field", `{"query":"go","limit":5}`, "$.limit"},
+		{"invalid JSON", `{"query":`, "$"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := wrapper.Call(json.RawMessage(tt.args))
+			var argsErr *ToolArgumentsError
+			if !errors.As(err, &argsErr) || len(argsErr.Errors) != 1 || argsErr.Errors[0].Path != tt.path {
+				t.Fatalf("expected argument error at %s, got %v", tt.path, err)
+			}
+		})
+	}
+	if called {
+		t.Fatal("tool must not run with invalid arguments")
+	}
+
+	if _, err := wrapper.Call(json.RawMessage(`{"query":"go","sort":"date"}`)); err != nil || !called {
+		t.Fatalf("expected valid call, got %v", err)
+	}
+}